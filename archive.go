@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ollama/ollama/api"
+)
+
+// archiveFolder is the sessions folder --auto-archive saves into.
+const archiveFolder = "archive"
+
+const titleInstruction = "Give this conversation a short title of three to six words. Reply with the title only, without quotes or punctuation."
+
+// slugify turns a title into a session name part: lower case letters and
+// digits joined by single dashes, at most 48 characters. Apostrophes are
+// dropped so that "Go's" becomes "gos".
+func slugify(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if r == '\'' || r == '’' {
+			continue
+		}
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	slug := b.String()
+	if len(slug) > 48 {
+		slug = strings.TrimRight(slug[:48], "-")
+	}
+	return slug
+}
+
+// archivable reports whether the savable part of the conversation has at
+// least one exchange.
+func (s *session) archivable() bool {
+	var user, assistant bool
+	for _, t := range s.persistedTurns() {
+		user = user || t.Role == "user"
+		assistant = assistant || t.Role == "assistant"
+	}
+	return user && assistant
+}
+
+// autoArchive saves the conversation under a title the model suggests, in
+// the archive folder of the sessions directory. Incognito sessions and
+// conversations without an exchange are skipped.
+func (s *session) autoArchive() {
+	if s.incognito || !s.archivable() {
+		return
+	}
+	fmt.Fprintln(ui, Dim+"🗄️  Archiving the conversation..."+Reset)
+	// The title becomes a file name, so it is drawn only from the turns
+	// that are saved.
+	msgs := append(s.turnsRequest(s.persistedTurns()), api.Message{Role: "user", Content: titleInstruction})
+	title, err := s.complete(msgs)
+	slug := slugify(title)
+	if err != nil || slug == "" {
+		slug = "conversation"
+	}
+
+	base := archiveFolder + "/" + time.Now().Format("2006-01-02") + "-" + slug
+	name := base
+	for n := 2; ; n++ {
+		if _, err := os.Stat(s.sessionPath(name)); errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		name = fmt.Sprintf("%s-%d", base, n)
+	}
+	path, err := s.saveSession(name)
+	if err != nil {
+		fmt.Fprintf(ui, "%s❌ Archiving failed:%s %v\n", Red, Reset, err)
+		return
+	}
+	fmt.Fprintf(ui, "%s🗄️  Archived as %s%s\n   %s\n", Green, name, Reset, path)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ollama/ollama/api"
+	"github.com/ollama/ollama/types/model"
+)
+
+// maxAttachmentSize caps how much of a file /attach will read.
+const maxAttachmentSize = 20 << 20
+
+// attachment is a file queued for the next user message.
+type attachment struct {
+	name string
+	kind string // "image" or "text"
+	data []byte
+}
+
+// cmdAttach queues a text file or an image for the next message. Images go
+// to vision models through Message.Images; text is inlined into the message.
+// Other documents, such as PDFs, are refused.
+func (s *session) cmdAttach(args []string) {
+	if len(args) == 0 {
+		if len(s.attachments) == 0 {
+			fmt.Fprintln(ui, "📎 Usage: /attach <path>  (a text file or an image)")
+			return
+		}
+		fmt.Fprintf(ui, "%s📎 Queued for next message:%s\n", Yellow, Reset)
+		for _, a := range s.attachments {
+			fmt.Fprintf(ui, "  - %s\n", a.describe())
+		}
+		return
+	}
+
+	path := strings.Join(args, " ")
+	a, err := loadAttachment(path)
+	if err != nil {
+		fmt.Fprintf(ui, "%s❌ Cannot attach %s:%s %v\n", Red, path, Reset, err)
+		return
+	}
+	if a.kind == "image" && !s.hasCapability(model.CapabilityVision) {
+		fmt.Fprintf(ui, "%s❌ %s does not accept images.%s\n", Red, s.model, Reset)
+		return
+	}
+	s.attachments = append(s.attachments, a)
+	fmt.Fprintf(ui, "%s📎 Attached%s %s\n", Green, Reset, a.describe())
+}
+
+func loadAttachment(path string) (attachment, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return attachment{}, err
+	}
+	if info.IsDir() {
+		return attachment{}, fmt.Errorf("is a directory")
+	}
+	if info.Size() > maxAttachmentSize {
+		return attachment{}, fmt.Errorf("file is %s, the limit is %s", formatBytes(info.Size()), formatBytes(maxAttachmentSize))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return attachment{}, err
+	}
+
+	a := attachment{name: filepath.Base(path), data: data}
+	switch ct := http.DetectContentType(data); {
+	case ct == "image/png" || ct == "image/jpeg" || ct == "image/gif" || ct == "image/webp":
+		a.kind = "image"
+	case utf8.Valid(data) && !bytes.ContainsRune(data, 0):
+		a.kind = "text"
+	default:
+		return attachment{}, fmt.Errorf("unsupported file type %s; only text files and images can be attached", ct)
+	}
+	return a, nil
+}
+
+func (a attachment) describe() string {
+	return fmt.Sprintf("%s%s%s (%s, %s)", Cyan, a.name, Reset, a.kind, formatBytes(int64(len(a.data))))
+}
+
+// applyAttachments moves the queued attachments onto msg and prints them as
+// part of the turn.
+func (s *session) applyAttachments(msg *api.Message) {
+	if len(s.attachments) == 0 {
+		return
+	}
+	var inlined strings.Builder
+	for _, a := range s.attachments {
+		fmt.Fprintf(ui, "%s📎 %s%s\n", Dim, a.describe(), Reset)
+		switch a.kind {
+		case "image":
+			msg.Images = append(msg.Images, api.ImageData(a.data))
+		case "text":
+			fmt.Fprintf(&inlined, "File: %s\n```\n%s\n```\n\n", a.name, strings.TrimRight(string(a.data), "\n"))
+		}
+	}
+	if inlined.Len() > 0 {
+		msg.Content = inlined.String() + msg.Content
+	}
+	s.attachments = nil
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
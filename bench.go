@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ollama/ollama/api"
+	"github.com/ollama/ollama/envconfig"
+)
+
+// benchTexts are embedded by /bench-embed when no file is given.
+var benchTexts = []string{
+	"The quick brown fox jumps over the lazy dog.",
+	"Ollama runs large language models locally on your own hardware.",
+	"Retrieval-augmented generation combines search with text generation.",
+	"Go is a statically typed, compiled programming language.",
+	"Embeddings map text to points in a high-dimensional vector space.",
+	"The mitochondria is the powerhouse of the cell.",
+	"A binary search runs in logarithmic time on a sorted array.",
+	"Paris is the capital and most populous city of France.",
+}
+
+// latencyStats summarises a set of request durations.
+type latencyStats struct {
+	min, max, total time.Duration
+	n               int
+}
+
+func (l *latencyStats) add(d time.Duration) {
+	if l.n == 0 || d < l.min {
+		l.min = d
+	}
+	if d > l.max {
+		l.max = d
+	}
+	l.total += d
+	l.n++
+}
+
+func (l latencyStats) avg() time.Duration {
+	if l.n == 0 {
+		return 0
+	}
+	return l.total / time.Duration(l.n)
+}
+
+func (l latencyStats) String() string {
+	return fmt.Sprintf("min %s · avg %s · max %s",
+		l.min.Round(time.Millisecond), l.avg().Round(time.Millisecond), l.max.Round(time.Millisecond))
+}
+
+// cmdBenchEmbed embeds a set of texts one request at a time and reports
+// latency and throughput for the embedding model.
+func (s *session) cmdBenchEmbed(args []string) {
+	texts := benchTexts
+	count := 32
+	if len(args) > 0 {
+		if n, err := strconv.Atoi(args[0]); err == nil {
+			if n <= 0 {
+				fmt.Fprintln(ui, "📏 Usage: /bench-embed [count|file]")
+				return
+			}
+			count = n
+		} else {
+			lines, err := readLines(strings.Join(args, " "))
+			if err != nil {
+				fmt.Fprintf(ui, "%s❌ Cannot read texts:%s %v\n", Red, Reset, err)
+				return
+			}
+			if len(lines) == 0 {
+				fmt.Fprintln(ui, Yellow+"⚠️  The file has no non-empty lines."+Reset)
+				return
+			}
+			texts, count = lines, len(lines)
+		}
+	}
+
+	ctx, cancel := s.requestContext()
+	defer cancel()
+
+	fmt.Fprintf(ui, "%s📏 Benchmarking %s with %d texts...%s\n", Yellow, s.embedModel, count, Reset)
+	fmt.Fprintln(ui, Dim+"   The first call may include loading the model."+Reset)
+
+	var (
+		lat       latencyStats
+		first     time.Duration
+		load      time.Duration
+		dimension int
+	)
+	began := time.Now()
+	for i := 0; i < count; i++ {
+		start := time.Now()
+		res, err := s.client.Embed(ctx, &api.EmbedRequest{Model: s.embedModel, Input: texts[i%len(texts)]})
+		if err != nil {
+			fmt.Fprintf(ui, "%s❌ Embedding failed:%s %v\n", Red, Reset, err)
+			return
+		}
+		d := time.Since(start)
+		if i == 0 {
+			first, load = d, res.LoadDuration
+			if len(res.Embeddings) > 0 {
+				dimension = len(res.Embeddings[0])
+			}
+			continue
+		}
+		lat.add(d)
+	}
+	elapsed := time.Since(began)
+
+	fmt.Fprintf(ui, "%s📏 Embedding Benchmark (%s):%s\n", Yellow, s.embedModel, Reset)
+	fmt.Fprintf(ui, "  Texts:      %d\n", count)
+	fmt.Fprintf(ui, "  Dimension:  %d\n", dimension)
+	fmt.Fprintf(ui, "  First call: %s (model load %s)\n", first.Round(time.Millisecond), load.Round(time.Millisecond))
+	if lat.n > 0 {
+		fmt.Fprintf(ui, "  Latency:    %s\n", lat)
+		fmt.Fprintf(ui, "  Throughput: %s%.1f embeddings/s%s (excluding first call)\n", Green, float64(lat.n)/lat.total.Seconds(), Reset)
+	}
+	fmt.Fprintf(ui, "  Total:      %s\n", elapsed.Round(time.Millisecond))
+}
+
+// readLines returns the non-empty, trimmed lines of a file.
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for sc.Scan() {
+		if line := strings.TrimSpace(sc.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, sc.Err()
+}
+
+// pingTimeout bounds each /ping request.
+const pingTimeout = 5 * time.Second
+
+// cmdPing times a series of heartbeat requests to the server, which do no
+// model work, so the round trips measure the connection alone.
+func (s *session) cmdPing(args []string) {
+	count := 5
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n <= 0 {
+			fmt.Fprintln(ui, "📡 Usage: /ping [count]")
+			return
+		}
+		count = n
+	}
+
+	fmt.Fprintf(ui, "%s📡 Pinging %s%s\n", Yellow, envconfig.Host().Redacted(), Reset)
+	var stats latencyStats
+	failed := 0
+	for i := 1; i <= count; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+		start := time.Now()
+		err := s.client.Heartbeat(ctx)
+		elapsed := time.Since(start)
+		cancel()
+		if err != nil {
+			failed++
+			fmt.Fprintf(ui, "  %3d  %s✗ %v%s\n", i, Red, err, Reset)
+			continue
+		}
+		stats.add(elapsed)
+		fmt.Fprintf(ui, "  %3d  %s✓%s %10s\n", i, Green, Reset, elapsed.Round(10*time.Microsecond))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	version, err := s.client.Version(ctx)
+	if err != nil {
+		version = Red + "unavailable (" + err.Error() + ")" + Reset
+	}
+
+	fmt.Fprintf(ui, "\n  %-10s %d sent, %d ok, %d failed\n", "requests", count, count-failed, failed)
+	if stats.n > 0 {
+		fmt.Fprintf(ui, "  %-10s %s\n", "latency", stats)
+	}
+	fmt.Fprintf(ui, "  %-10s %s\n", "version", version)
+	if stats.n > 0 && stats.avg() > 100*time.Millisecond {
+		fmt.Fprintln(ui, Dim+"💡 Round trips this slow point at the network rather than the model."+Reset)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import "strings"
+
+// codeBlock is a fenced code block found in a Markdown response.
+type codeBlock struct {
+	lang string
+	code string
+}
+
+// extractCodeBlocks returns the fenced (``` or ~~~) code blocks of a
+// Markdown document in order. An unterminated final block is included.
+func extractCodeBlocks(markdown string) []codeBlock {
+	var (
+		blocks []codeBlock
+		cur    *codeBlock
+		fence  string
+		body   strings.Builder
+	)
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if cur == nil {
+			for _, f := range []string{"```", "~~~"} {
+				if strings.HasPrefix(trimmed, f) {
+					fence = f
+					cur = &codeBlock{lang: strings.TrimSpace(strings.TrimLeft(trimmed, f[:1]))}
+					body.Reset()
+					break
+				}
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			cur.code = body.String()
+			blocks = append(blocks, *cur)
+			cur = nil
+			continue
+		}
+		body.WriteString(line)
+		body.WriteByte('\n')
+	}
+	if cur != nil {
+		cur.code = body.String()
+		blocks = append(blocks, *cur)
+	}
+	return blocks
+}
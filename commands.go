@@ -0,0 +1,338 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// commandHelp lists the slash commands shown by /help, in display order.
+var commandHelp = []struct {
+	usage string
+	desc  string
+}{
+	{"/help", "show this list"},
+	{"/status", "show the current session settings"},
+	{"/config", "show the effective configuration and its sources"},
+	{"/system [reload]", "show the system prompt, or re-read it from its file"},
+	{"/lang <code>|off", "always respond in the given language"},
+	{"/always <instruction>|list|clear", "add a standing instruction for every response"},
+	{"/options", "show the model options sent with each request"},
+	{"/preset [name|off]", "list option presets, or apply one"},
+	{"/ctx <tokens>|default", "set the context window (num_ctx)"},
+	{"/budget <duration>|off", "stop responses after a time and keep what arrived"},
+	{"/gpu <layers>|auto", "set how many layers are offloaded to the GPU"},
+	{"/attach <path>", "attach a text file or image to your next message"},
+	{"/ping [count]", "measure round-trip latency to the server"},
+	{"/pull <model>", "download a model (re-run to resume)"},
+	{"/bench-embed [count|file]", "measure embedding latency and throughput"},
+	{"/continue", "extend a response that was cut off"},
+	{"/refine <instruction>", "ask for a revised version of the last answer"},
+	{"/shorter", "regenerate the last answer more concisely"},
+	{"/longer", "regenerate the last answer in more detail"},
+	{"/history [tag]", "list the turns of the conversation, or those tagged"},
+	{"/tag <label> [n]", "tag the last turn, or turn n of /history"},
+	{"/tags", "list the tags in use"},
+	{"/insert <role> \"text\"", "add a user, assistant or system turn by hand"},
+	{"/raw-last", "reprint the last response verbatim"},
+	{"/rendered-last", "reprint the last response with Markdown styling"},
+	{"/recall [N]", "list recent responses, or reprint response N"},
+	{"/meta", "show server metadata for the last response"},
+	{"/profile-session", "break down where response time went this session"},
+	{"/count-messages", "count turns and average length by role"},
+	{"/lint [-f]", "check the code blocks of the last response"},
+	{"/pipe [-c] <command>", "send the last response (or code block) to a command"},
+	{"/minimize [-t sim] [text]|restore", "cut the history to the turns that reproduce the last answer"},
+	{"/summarize", "replace older turns with a summary"},
+	{"/fim [-m <model>] <prefix> --- <suffix>", "fill in the code between a prefix and a suffix"},
+	{"/sweep-temp [-t list] <prompt>", "run a prompt at several temperatures"},
+	{"/vary <option> <v1,v2,...>", "regenerate the last answer once per option value"},
+	{"/persona-compare <p1> <p2> <prompt>", "run a prompt under two personas"},
+	{"/probe-ctx [step] [max]", "measure how much context the model really uses"},
+	{"/save <name>", "save the conversation (folder/name for a folder)"},
+	{"/load <name>", "load a saved conversation"},
+	{"/sessions", "list saved sessions by folder"},
+	{"/session rename <old> <new>", "rename a saved session"},
+	{"/session move <name> <folder>", "move a saved session into a folder"},
+	{"/export <file.md> [filters]", "export as Markdown (filters: --roles r,... --tags t,...)"},
+	{"/export-share <file.md> [filters]", "export with a metadata header for sharing"},
+	{"/incognito [on|off]", "keep new turns out of saved sessions"},
+	{"/search-input <term>", "find and resend a prompt you typed before"},
+}
+
+const (
+	shorterInstruction = "Make that more concise."
+	longerInstruction  = "Expand on that with more detail."
+)
+
+// languageNames maps common language codes to the names used in the
+// response-language instruction. Anything else is passed through as typed.
+var languageNames = map[string]string{
+	"ar": "Arabic",
+	"de": "German",
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"hi": "Hindi",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"nl": "Dutch",
+	"pl": "Polish",
+	"pt": "Portuguese",
+	"ru": "Russian",
+	"tr": "Turkish",
+	"uk": "Ukrainian",
+	"zh": "Chinese",
+}
+
+func languageName(lang string) string {
+	if name, ok := languageNames[strings.ToLower(lang)]; ok {
+		return name
+	}
+	return lang
+}
+
+func langInstruction(lang string) string {
+	return fmt.Sprintf("Always respond in %s, regardless of the language of the question.", languageName(lang))
+}
+
+// handleCommand runs a slash command typed at the prompt.
+func (s *session) handleCommand(line string) {
+	fields := strings.Fields(line)
+	name, args := fields[0], fields[1:]
+	// rest is the text after the command name, with its spacing and quoting
+	// intact.
+	rest := strings.TrimSpace(strings.TrimPrefix(line, name))
+
+	switch name {
+	case "/help":
+		fmt.Fprintf(ui, "%s📖 Commands:%s\n", Yellow, Reset)
+		for _, c := range commandHelp {
+			fmt.Fprintf(ui, "  %s%-36s%s %s\n", Cyan, c.usage, Reset, c.desc)
+		}
+	case "/status":
+		s.printStatus()
+	case "/config":
+		s.cmdConfig()
+	case "/system":
+		s.cmdSystem(args)
+	case "/lang":
+		s.cmdLang(args)
+	case "/always":
+		s.cmdAlways(rest)
+	case "/options":
+		s.cmdOptions()
+	case "/preset":
+		s.cmdPreset(args)
+	case "/ctx":
+		s.cmdCtx(args)
+	case "/budget":
+		s.cmdBudget(args)
+	case "/gpu":
+		s.cmdGPU(args)
+	case "/attach":
+		s.cmdAttach(args)
+	case "/ping":
+		s.cmdPing(args)
+	case "/pull":
+		s.cmdPull(args)
+	case "/bench-embed":
+		s.cmdBenchEmbed(args)
+	case "/continue":
+		s.cmdContinue()
+	case "/refine":
+		s.refine(strings.Join(args, " "))
+	case "/shorter":
+		s.refine(shorterInstruction)
+	case "/longer":
+		s.refine(longerInstruction)
+	case "/history":
+		s.cmdHistory(strings.Join(args, " "))
+	case "/tag":
+		s.cmdTag(args)
+	case "/tags":
+		s.cmdTags()
+	case "/insert":
+		s.cmdInsert(rest)
+	case "/raw-last":
+		s.cmdLastResponse(false)
+	case "/rendered-last":
+		s.cmdLastResponse(true)
+	case "/recall":
+		s.cmdRecall(args)
+	case "/meta":
+		s.cmdMeta()
+	case "/profile-session":
+		s.cmdProfileSession()
+	case "/count-messages":
+		s.cmdCountMessages()
+	case "/lint":
+		s.cmdLint(args)
+	case "/pipe":
+		s.cmdPipe(rest)
+	case "/minimize":
+		s.cmdMinimize(args)
+	case "/summarize":
+		s.cmdSummarize()
+	case "/fim":
+		s.cmdFIM(rest)
+	case "/sweep-temp":
+		s.cmdSweepTemp(args)
+	case "/vary":
+		s.cmdVary(args)
+	case "/persona-compare":
+		s.cmdPersonaCompare(args)
+	case "/probe-ctx":
+		s.cmdProbeCtx(args)
+	case "/save":
+		s.cmdSave(args)
+	case "/load":
+		s.cmdLoad(args)
+	case "/sessions":
+		s.cmdSessions()
+	case "/session":
+		s.cmdSession(args)
+	case "/search-input":
+		s.cmdSearchInput(rest)
+	case "/export":
+		s.cmdExport(args, false)
+	case "/export-share":
+		s.cmdExport(args, true)
+	case "/incognito":
+		s.cmdIncognito(args)
+	default:
+		fmt.Fprintf(ui, "%s❓ Unknown command:%s %s (type /help)\n", Red, Reset, name)
+	}
+}
+
+func (s *session) printStatus() {
+	row := func(label string, format string, a ...any) {
+		fmt.Fprintf(ui, "  %-12s "+format+"\n", append([]any{label + ":"}, a...)...)
+	}
+	onOff := func(b bool) string {
+		if b {
+			return "on"
+		}
+		return "off"
+	}
+
+	fmt.Fprintf(ui, "%s📊 Session Status:%s\n", Yellow, Reset)
+	row("Model", "%s%s%s", Cyan, s.model, Reset)
+	row("Messages", "%d", len(s.messages))
+	numCtx := "server default"
+	if _, ok := s.optionInt("num_ctx"); ok {
+		numCtx = "num_ctx"
+	}
+	row("Context", "~%d / %d tokens (%s)", estimateTokens(s.requestMessages()), s.contextWindow(), numCtx)
+	lang := "off"
+	if s.lang != "" {
+		lang = languageName(s.lang)
+	}
+	row("Language", "%s", lang)
+	preset := "none"
+	if s.preset != "" {
+		preset = s.preset
+	}
+	row("Preset", "%s", preset)
+	row("Always", "%d instruction(s)", len(s.standing))
+	row("Incognito", "%s", onOff(s.incognito))
+	row("Thinking", "%s", s.thinking)
+	gpu := "auto"
+	if n, ok := s.optionInt("num_gpu"); ok {
+		gpu = fmt.Sprintf("%d layers", n)
+	}
+	row("GPU", "%s", gpu)
+	budget := "off"
+	if s.budget > 0 {
+		budget = s.budget.String()
+	}
+	row("Budget", "%s", budget)
+}
+
+func (s *session) cmdLang(args []string) {
+	if len(args) == 0 {
+		if s.lang == "" {
+			fmt.Fprintln(ui, "🌐 No response language set. Usage: /lang <code>|off")
+		} else {
+			fmt.Fprintf(ui, "🌐 Responding in %s\n", languageName(s.lang))
+		}
+		return
+	}
+	lang := strings.Join(args, " ")
+	if strings.EqualFold(lang, "off") {
+		s.lang = ""
+		fmt.Fprintln(ui, Green+"🌐 Response language cleared."+Reset)
+		return
+	}
+	s.lang = lang
+	fmt.Fprintf(ui, "%s🌐 Responses will be in %s.%s\n", Green, languageName(lang), Reset)
+}
+
+// standingInstructions renders the /always instructions for the system
+// prompt.
+func standingInstructions(rules []string) string {
+	var b strings.Builder
+	b.WriteString("Follow these instructions in every response:")
+	for _, r := range rules {
+		b.WriteString("\n- " + r)
+	}
+	return b.String()
+}
+
+func (s *session) cmdAlways(rest string) {
+	switch rest {
+	case "":
+		fmt.Fprintln(ui, "📌 Usage: /always <instruction> | /always list | /always clear")
+	case "list":
+		if len(s.standing) == 0 {
+			fmt.Fprintln(ui, "📌 No standing instructions.")
+			return
+		}
+		fmt.Fprintf(ui, "%s📌 Standing instructions:%s\n", Yellow, Reset)
+		for i, r := range s.standing {
+			fmt.Fprintf(ui, "  %d. %s\n", i+1, r)
+		}
+	case "clear":
+		n := len(s.standing)
+		s.standing = nil
+		fmt.Fprintf(ui, "%s📌 Cleared %d standing instruction(s).%s\n", Green, n, Reset)
+	default:
+		s.standing = append(s.standing, rest)
+		fmt.Fprintf(ui, "%s📌 Standing instruction %d added: %s%s\n", Green, len(s.standing), rest, Reset)
+	}
+}
+
+// refine asks the model to revise its last answer. The instruction is
+// recorded as an ordinary user turn so the revision stays in context.
+func (s *session) refine(instruction string) {
+	if instruction == "" {
+		fmt.Fprintln(ui, "✏️  Usage: /refine <instruction>")
+		return
+	}
+	if s.lastAssistant() < 0 {
+		fmt.Fprintln(ui, Yellow+"⚠️  Nothing to refine yet — ask something first."+Reset)
+		return
+	}
+	fmt.Fprintf(ui, "%s✏️  Refining:%s %s\n", Purple, Reset, instruction)
+	s.send(instruction)
+}
+
+func (s *session) cmdBudget(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(ui, "⏳ Usage: /budget <duration>|off  (e.g. /budget 10s)")
+		return
+	}
+	if args[0] == "off" {
+		s.budget = 0
+		fmt.Fprintln(ui, Green+"⏳ Time budget off."+Reset)
+		return
+	}
+	d, err := time.ParseDuration(args[0])
+	if err != nil || d <= 0 {
+		fmt.Fprintf(ui, "%s❌ Invalid duration %q; use e.g. 10s or 1m30s%s\n", Red, args[0], Reset)
+		return
+	}
+	s.budget = d
+	fmt.Fprintf(ui, "%s⏳ Responses will stop after %s, keeping what has arrived.%s\n", Green, d, Reset)
+}
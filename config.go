@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Config holds the settings that can be given in the config file. Command
+// line flags are bound to the same fields and take precedence over the file.
+type Config struct {
+	Lang string `json:"lang,omitempty"`
+
+	// Timeout bounds a whole response, FirstChunkTimeout the wait for its
+	// first streamed chunk and IdleTimeout the gap between later chunks.
+	// Zero means no limit.
+	Timeout           Duration `json:"timeout,omitempty"`
+	FirstChunkTimeout Duration `json:"first_chunk_timeout,omitempty"`
+	IdleTimeout       Duration `json:"idle_timeout,omitempty"`
+	Debug             bool     `json:"debug,omitempty"`
+
+	// NoSystem leaves out the system message, rather than sending the
+	// system file or the default.
+	NoSystem bool `json:"no_system,omitempty"`
+
+	// Variables are extra {{name}} placeholders for the system prompt.
+	Variables map[string]string `json:"variables,omitempty"`
+
+	// Options are sent as ChatRequest.Options. The --options flag is merged
+	// over them, and the individual option flags over that.
+	Options map[string]any `json:"options,omitempty"`
+
+	// Presets are named option bundles. Preset is applied over Options at
+	// startup, below the option flags; /preset switches it later.
+	Presets map[string]map[string]any `json:"presets,omitempty"`
+	Preset  string                    `json:"preset,omitempty"`
+
+	// SessionsDir is where /save writes conversations.
+	SessionsDir string `json:"sessions_dir,omitempty"`
+
+	// ContextFiles are loaded as reference messages after the system
+	// message, up to ContextMaxTokens in total.
+	ContextFiles     []string `json:"context_files,omitempty"`
+	ContextMaxTokens int      `json:"context_max_tokens,omitempty"`
+
+	// AutoSummarizeAt enables summarizing the oldest turns once the
+	// estimated context reaches a token count ("6000"), a share of the
+	// context window ("80%") or the default share ("on").
+	AutoSummarizeAt string `json:"auto_summarize_at,omitempty"`
+
+	// PersonasDir holds persona system prompts as <name>.txt files.
+	PersonasDir string `json:"personas_dir,omitempty"`
+
+	// InputHistory is the file typed prompts are recorded in; empty turns
+	// recording off.
+	InputHistory string `json:"input_history,omitempty"`
+
+	// RecallSize is how many recent responses /recall keeps within reach.
+	RecallSize int `json:"recall_size,omitempty"`
+
+	// AutoArchive saves every conversation with an exchange on exit, named
+	// after a title the model suggests, into the sessions' archive folder.
+	AutoArchive bool `json:"auto_archive,omitempty"`
+
+	// Incognito starts the session with history persistence off.
+	Incognito bool `json:"incognito,omitempty"`
+
+	// ShareFields selects the front matter fields /export-share writes.
+	ShareFields []string `json:"share_fields,omitempty"`
+
+	// AllowShell enables commands that run external programs.
+	AllowShell bool `json:"allow_shell,omitempty"`
+
+	// Thinking controls reasoning models; see ThinkingConfig.
+	Thinking ThinkingConfig `json:"thinking"`
+
+	// TrimLeadingNewlines strips newlines from the very start of each
+	// response; newlines later in the response are kept.
+	TrimLeadingNewlines bool `json:"trim_leading_newlines"`
+
+	// FormatSchema is a JSON schema, inline or as a file path, that
+	// responses must follow. SchemaRetry re-requests an invalid response
+	// once with the validation errors.
+	FormatSchema string `json:"format_schema,omitempty"`
+	SchemaRetry  bool   `json:"schema_retry,omitempty"`
+
+	// AutoContinue is how many times a response cut off at the length
+	// limit is continued automatically and stitched into one turn, while it
+	// is shorter than AutoContinueMaxChars. Zero disables it.
+	AutoContinue         int `json:"auto_continue,omitempty"`
+	AutoContinueMaxChars int `json:"auto_continue_max_chars,omitempty"`
+
+	// LintCode checks the code blocks of every response with the formatter
+	// for their language, and LintShow prints the formatted version of
+	// blocks that differ. Formatters maps a language to a command that reads
+	// code on stdin and writes it formatted; Go uses the gofmt rules
+	// built in unless it has an entry.
+	LintCode   bool              `json:"lint_code,omitempty"`
+	LintShow   bool              `json:"lint_show,omitempty"`
+	Formatters map[string]string `json:"formatters,omitempty"`
+
+	// RetryEmpty re-sends a request up to this many times, with a fresh
+	// seed, when the reply has no content. Zero disables it.
+	RetryEmpty int `json:"retry_empty,omitempty"`
+
+	// UserPrefix and UserSuffix wrap every user message when it is sent.
+	// History keeps the text as typed, so changing them applies to the
+	// whole conversation on the next request and saved transcripts never
+	// contain the wrapper.
+	UserPrefix string `json:"user_prefix,omitempty"`
+	UserSuffix string `json:"user_suffix,omitempty"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		Timeout:           Duration(5 * time.Minute),
+		FirstChunkTimeout: Duration(2 * time.Minute),
+		IdleTimeout:       Duration(60 * time.Second),
+		SessionsDir:       defaultSessionsDir(),
+
+		InputHistory: defaultInputHistoryPath(),
+		PersonasDir:  defaultPersonasDir(),
+
+		ContextMaxTokens: 8000,
+		RecallSize:       10,
+		Thinking:         defaultThinkingConfig(),
+
+		TrimLeadingNewlines: true,
+		ShareFields:         shareFields,
+
+		AutoContinueMaxChars: 100_000,
+	}
+}
+
+// listFlag is a repeatable flag.Value. Values given on the command line
+// replace those from the config file rather than adding to them.
+type listFlag struct {
+	values *[]string
+	set    bool
+}
+
+func (f *listFlag) String() string {
+	if f == nil || f.values == nil {
+		return ""
+	}
+	return strings.Join(*f.values, ", ")
+}
+
+func (f *listFlag) Set(value string) error {
+	if !f.set {
+		*f.values = nil
+		f.set = true
+	}
+	*f.values = append(*f.values, value)
+	return nil
+}
+
+// Duration is a time.Duration written as a string ("90s", "5m") in JSON.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"90s\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "config.json"
+	}
+	return filepath.Join(dir, "ollama-terminal", "config.json")
+}
+
+// configPathFromArgs finds --config among the command line arguments. The
+// file has to be read before the flags are parsed so that flags can override
+// it.
+func configPathFromArgs(args []string) string {
+	for i, arg := range args {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "config" {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return defaultConfigPath()
+}
+
+// loadConfig reads the config file at path over cfg and marks the settings
+// it contains in src. A missing file is not an error.
+func loadConfig(path string, cfg *Config, src configSources) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	src.addFile(data)
+	return nil
+}
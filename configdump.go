@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ollama/ollama/envconfig"
+)
+
+// configSources records where each resolved setting came from, keyed by its
+// dotted config path ("thinking.level", "options.seed"). Settings missing
+// from the map have their default value.
+type configSources map[string]string
+
+// flagKeys maps the flags whose names don't follow from their config key.
+// Other flags set the key named like the flag with dashes as underscores.
+var flagKeys = map[string][]string{
+	"think":               {"thinking.level"},
+	"show-thinking":       {"thinking.show"},
+	"thinking-render":     {"thinking.render"},
+	"quiet-thinking":      {"thinking.show", "thinking.render"},
+	"thinking-dot-tokens": {"thinking.dot_tokens"},
+	"store-thinking":      {"thinking.store"},
+	"context-file":        {"context_files"},
+	"share-field":         {"share_fields"},
+	"temperature":         {"options.temperature"},
+	"seed":                {"options.seed"},
+	"num-ctx":             {"options.num_ctx"},
+}
+
+// secretKey matches setting names whose values are never printed.
+var secretKey = regexp.MustCompile(`(?i)^(.*_)?(api_?key|key|token|secret|password|passwd|auth|authorization|headers?|cookie)$`)
+
+// addFile marks every key present in the config file data.
+func (src configSources) addFile(data []byte) {
+	var m map[string]any
+	if json.Unmarshal(data, &m) == nil {
+		src.addTree("", m)
+	}
+}
+
+func (src configSources) addTree(prefix string, m map[string]any) {
+	for k, v := range m {
+		if sub, ok := v.(map[string]any); ok {
+			src.addTree(prefix+k+".", sub)
+			continue
+		}
+		src[prefix+k] = "config"
+	}
+}
+
+// addFlag marks the keys set by the named command line flag.
+func (src configSources) addFlag(name string) {
+	keys, ok := flagKeys[name]
+	if !ok {
+		keys = []string{strings.ReplaceAll(name, "-", "_")}
+	}
+	for _, k := range keys {
+		src[k] = "flag"
+	}
+}
+
+// configEntry is one resolved setting.
+type configEntry struct {
+	key, value string
+}
+
+// configEntries flattens cfg into its settings in declaration order, with
+// map entries sorted by key and values shown as JSON.
+func configEntries(cfg Config) []configEntry {
+	var entries []configEntry
+	var walk func(prefix string, v reflect.Value)
+	walk = func(prefix string, v reflect.Value) {
+		t := v.Type()
+		for i := range t.NumField() {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			f := v.Field(i)
+			switch {
+			case f.Kind() == reflect.Struct:
+				walk(prefix+name+".", f)
+			case f.Kind() == reflect.Map && f.Len() > 0:
+				keys := f.MapKeys()
+				slices.SortFunc(keys, func(a, b reflect.Value) int { return strings.Compare(a.String(), b.String()) })
+				for _, k := range keys {
+					entries = append(entries, configEntry{prefix + name + "." + k.String(), jsonValue(f.MapIndex(k).Interface())})
+				}
+			default:
+				entries = append(entries, configEntry{prefix + name, jsonValue(f.Interface())})
+			}
+		}
+	}
+	walk("", reflect.ValueOf(cfg))
+	return entries
+}
+
+func jsonValue(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// printConfig writes the resolved configuration with the source of each
+// setting. The Ollama host, which comes from the environment rather than the
+// config file, is listed first. Without color the listing is plain text.
+func printConfig(w io.Writer, color bool, path string, cfg Config, src configSources) {
+	yellow, dim, reset := Yellow, Dim, Reset
+	if !color {
+		yellow, dim, reset = "", "", ""
+	}
+	fmt.Fprintf(w, "%s⚙️  Effective configuration%s (file: %s)\n", yellow, reset, path)
+	hostSource := "default"
+	if os.Getenv("OLLAMA_HOST") != "" {
+		hostSource = "env"
+	}
+	entries := append([]configEntry{{"OLLAMA_HOST", envconfig.Host().Redacted()}}, configEntries(cfg)...)
+	for _, e := range entries {
+		source := src[e.key]
+		switch {
+		case e.key == "OLLAMA_HOST":
+			source = hostSource
+		case source == "":
+			source = "default"
+			for k, v := range src {
+				if strings.HasPrefix(k, e.key+".") {
+					source = v
+				}
+			}
+		}
+		value := e.value
+		if secretKey.MatchString(e.key[strings.LastIndex(e.key, ".")+1:]) {
+			value = `"<redacted>"`
+		}
+		fmt.Fprintf(w, "  %-28s %s %s(%s)%s\n", e.key, value, dim, source, reset)
+	}
+}
+
+func (s *session) cmdConfig() {
+	printConfig(ui, true, s.configPath, s.config, s.configSources)
+	fmt.Fprintln(ui, Dim+"Values are as resolved at startup; /status shows settings changed since."+Reset)
+}
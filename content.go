@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ollama/ollama/api"
+)
+
+// contentPart is one element of a message content given as a list of typed
+// parts rather than a plain string.
+type contentPart struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+
+	// Data is base64 data for binary parts such as images.
+	Data string `json:"data,omitempty"`
+
+	// ImageURL is an image reference, either a plain URL string or an
+	// object with a url field.
+	ImageURL json.RawMessage `json:"image_url,omitempty"`
+}
+
+// url returns the part's image URL, if any.
+func (p contentPart) url() string {
+	if len(p.ImageURL) == 0 {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(p.ImageURL, &s) == nil {
+		return s
+	}
+	var obj struct {
+		URL string `json:"url"`
+	}
+	json.Unmarshal(p.ImageURL, &obj)
+	return obj.URL
+}
+
+// image returns the decoded image of an image part carrying inline data,
+// either in Data or as a base64 data: URL.
+func (p contentPart) image() (api.ImageData, bool) {
+	data := p.Data
+	if u := p.url(); data == "" && strings.HasPrefix(u, "data:") {
+		if _, encoded, ok := strings.Cut(u, ";base64,"); ok {
+			data = encoded
+		}
+	}
+	if data == "" {
+		return nil, false
+	}
+	b, err := base64.StdEncoding.DecodeString(data)
+	return b, err == nil
+}
+
+// decodeContent parses a message content that is either a JSON string or a
+// list of parts. parts is nil for a plain string.
+func decodeContent(raw json.RawMessage) (text string, parts []contentPart, err error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || raw[0] != '[' {
+		err = json.Unmarshal(raw, &text)
+		return text, nil, err
+	}
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return "", nil, fmt.Errorf("content parts: %w", err)
+	}
+	return partsText(parts), parts, nil
+}
+
+// partsText renders parts as display text: text parts verbatim and a short
+// marker for everything else.
+func partsText(parts []contentPart) string {
+	var b strings.Builder
+	for _, p := range parts {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		switch p.Type {
+		case "text":
+			b.WriteString(p.Text)
+		case "image", "image_url":
+			if u := p.url(); u != "" && !strings.HasPrefix(u, "data:") {
+				fmt.Fprintf(&b, "[image: %s]", u)
+			} else {
+				b.WriteString("[image]")
+			}
+		default:
+			fmt.Fprintf(&b, "[%s part]", p.Type)
+		}
+	}
+	return b.String()
+}
+
+// partsContent is the content sent back to the model for a turn that
+// arrived as parts: its text parts only. Images travel in the message's
+// images, and the display markers of partsText are left out.
+func partsContent(parts []contentPart) string {
+	var texts []string
+	for _, p := range parts {
+		if p.Type == "text" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// partsCollector gathers the content of a streamed chat response chunk by
+// chunk, so that a response which arrived as parts keeps them. partsTransport
+// feeds it from the request context; see withPartsCollector.
+type partsCollector struct {
+	mu    sync.Mutex
+	parts []contentPart
+	typed bool
+}
+
+type partsCollectorKey struct{}
+
+// withPartsCollector returns a context whose chat requests report their
+// chunks to c.
+func withPartsCollector(ctx context.Context, c *partsCollector) context.Context {
+	return context.WithValue(ctx, partsCollectorKey{}, c)
+}
+
+// add records one chunk, given as its parts or, for a plain string, its
+// text. Text continuing text from the previous chunk is joined to it.
+func (c *partsCollector) add(text string, parts []contentPart) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if parts != nil {
+		c.typed = true
+	} else if text != "" {
+		parts = []contentPart{{Type: "text", Text: text}}
+	}
+	if n := len(c.parts); n > 0 && len(parts) > 0 && c.parts[n-1].Type == "text" && parts[0].Type == "text" {
+		c.parts[n-1].Text += parts[0].Text
+		parts = parts[1:]
+	}
+	c.parts = append(c.parts, parts...)
+}
+
+// result returns the collected parts, or nil if every chunk was a plain
+// string.
+func (c *partsCollector) result() []contentPart {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.typed {
+		return nil
+	}
+	return c.parts
+}
+
+// joinParts returns the parts of a response followed by those of its
+// continuation, or nil if neither arrived as parts.
+func joinParts(first, rest turn) []contentPart {
+	if first.Parts == nil && rest.Parts == nil {
+		return nil
+	}
+	var c partsCollector
+	c.add(first.Content, first.Parts)
+	c.add(rest.Content, rest.Parts)
+	return c.parts
+}
+
+// maxChunkSize bounds one line of a streamed response.
+const maxChunkSize = 16 << 20
+
+// partsTransport lets the api client read chat responses whose message
+// content arrives as typed parts. api.Message only decodes a string, so each
+// streamed line is rewritten on the way in: the parts become their display
+// text and inline images move to the message's images. Everything else
+// passes through untouched. A partsCollector in the request context is
+// handed each chunk's content, parts included.
+type partsTransport struct {
+	base http.RoundTripper
+}
+
+func (t partsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || !strings.HasSuffix(req.URL.Path, "/api/chat") {
+		return resp, err
+	}
+	collector, _ := req.Context().Value(partsCollectorKey{}).(*partsCollector)
+	body := resp.Body
+	pr, pw := io.Pipe()
+	go func() {
+		defer body.Close()
+		scanner := bufio.NewScanner(body)
+		scanner.Buffer(make([]byte, 0, 64<<10), maxChunkSize)
+		for scanner.Scan() {
+			line, text, parts := normalizeChatChunk(scanner.Bytes())
+			if collector != nil {
+				collector.add(text, parts)
+			}
+			if _, err := pw.Write(append(line, '\n')); err != nil {
+				return
+			}
+		}
+		pw.CloseWithError(scanner.Err())
+	}()
+	resp.Body = pr
+	resp.ContentLength = -1
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
+
+// normalizeChatChunk rewrites one streamed chat response so that a message
+// content given as parts becomes a string, and returns the content's text
+// and parts alongside. Lines it cannot parse, and lines whose content
+// already is a string, are returned as they are.
+func normalizeChatChunk(line []byte) (out []byte, text string, parts []contentPart) {
+	var chunk map[string]json.RawMessage
+	if json.Unmarshal(line, &chunk) != nil || chunk["message"] == nil {
+		return line, "", nil
+	}
+	var msg map[string]json.RawMessage
+	if json.Unmarshal(chunk["message"], &msg) != nil {
+		return line, "", nil
+	}
+	text, parts, err := decodeContent(msg["content"])
+	if err != nil || parts == nil {
+		return line, text, nil
+	}
+
+	var images []api.ImageData
+	if msg["images"] != nil && json.Unmarshal(msg["images"], &images) != nil {
+		return line, "", nil
+	}
+	for _, p := range parts {
+		if img, ok := p.image(); ok {
+			images = append(images, img)
+		}
+	}
+	msg["content"], _ = json.Marshal(text)
+	if len(images) > 0 {
+		msg["images"], _ = json.Marshal(images)
+	}
+	if chunk["message"], err = json.Marshal(msg); err != nil {
+		return line, "", nil
+	}
+	if out, err = json.Marshal(chunk); err != nil {
+		return line, "", nil
+	}
+	return out, text, parts
+}
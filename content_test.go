@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ollama/ollama/api"
+)
+
+func TestDecodeContent(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       string
+		wantText  string
+		wantParts int
+	}{
+		{"string", `"hello"`, "hello", 0},
+		{"empty string", `""`, "", 0},
+		{"text parts", `[{"type":"text","text":"a"},{"type":"text","text":"b"}]`, "a\nb", 2},
+		{"image url", `[{"type":"text","text":"see"},{"type":"image_url","image_url":{"url":"https://x/y.png"}}]`, "see\n[image: https://x/y.png]", 2},
+		{"inline image", `[{"type":"image","data":"aGk="}]`, "[image]", 1},
+		{"unknown part", `[{"type":"audio"}]`, "[audio part]", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, parts, err := decodeContent(json.RawMessage(tt.raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if text != tt.wantText || len(parts) != tt.wantParts {
+				t.Errorf("decodeContent(%s) = %q, %d parts; want %q, %d parts", tt.raw, text, len(parts), tt.wantText, tt.wantParts)
+			}
+		})
+	}
+}
+
+func TestTurnUnmarshal(t *testing.T) {
+	var plain turn
+	if err := json.Unmarshal([]byte(`{"role":"assistant","content":"hi","tags":["x"]}`), &plain); err != nil {
+		t.Fatal(err)
+	}
+	if plain.Content != "hi" || plain.Parts != nil || len(plain.Tags) != 1 {
+		t.Errorf("string content: got %+v", plain)
+	}
+
+	var parts turn
+	if err := json.Unmarshal([]byte(`{"role":"user","content":[{"type":"text","text":"look"},{"type":"image","data":"aGk="}]}`), &parts); err != nil {
+		t.Fatal(err)
+	}
+	if parts.Content != "look\n[image]" || len(parts.Parts) != 2 || len(parts.Images) != 1 || string(parts.Images[0]) != "hi" {
+		t.Errorf("parts content: got content %q, %d parts, %d images", parts.Content, len(parts.Parts), len(parts.Images))
+	}
+}
+
+func TestNormalizeChatChunk(t *testing.T) {
+	plain := `{"model":"m","message":{"role":"assistant","content":"hi"},"done":false}`
+	if got, text, parts := normalizeChatChunk([]byte(plain)); string(got) != plain || text != "hi" || parts != nil {
+		t.Errorf("string content: got %s, text %q, %d parts", got, text, len(parts))
+	}
+	for _, line := range []string{`{"error":"boom"}`, `not json`} {
+		if got, _, _ := normalizeChatChunk([]byte(line)); string(got) != line {
+			t.Errorf("%s was rewritten: %s", line, got)
+		}
+	}
+
+	var resp api.ChatResponse
+	line, _, parts := normalizeChatChunk([]byte(`{"model":"m","message":{"role":"assistant","content":[{"type":"text","text":"a"},{"type":"image","data":"aGk="}]},"done":true}`))
+	if err := json.Unmarshal(line, &resp); err != nil {
+		t.Fatalf("rewritten chunk does not decode: %v (%s)", err, line)
+	}
+	if resp.Message.Content != "a\n[image]" || len(resp.Message.Images) != 1 || !resp.Done || len(parts) != 2 {
+		t.Errorf("got content %q, %d images, done %v, %d parts", resp.Message.Content, len(resp.Message.Images), resp.Done, len(parts))
+	}
+}
+
+func TestPartsTransportStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"model":"m","message":{"role":"assistant","content":"plain "},"done":false}`)
+		fmt.Fprintln(w, `{"model":"m","message":{"role":"assistant","content":[{"type":"text","text":"parts"}]},"done":false}`)
+		fmt.Fprintln(w, `{"model":"m","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop"}`)
+	}))
+	defer srv.Close()
+
+	base, _ := url.Parse(srv.URL)
+	client := api.NewClient(base, &http.Client{Transport: partsTransport{base: http.DefaultTransport}})
+	var got strings.Builder
+	var collected partsCollector
+	ctx := withPartsCollector(context.Background(), &collected)
+	err := client.Chat(ctx, &api.ChatRequest{Model: "m"}, func(r api.ChatResponse) error {
+		got.WriteString(r.Message.Content)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != "plain parts" {
+		t.Errorf("streamed content = %q, want %q", got.String(), "plain parts")
+	}
+	if parts := collected.result(); len(parts) != 1 || parts[0].Text != "plain parts" {
+		t.Errorf("collected parts = %+v, want one text part %q", parts, "plain parts")
+	}
+}
+
+func TestPartsCollector(t *testing.T) {
+	var plain partsCollector
+	plain.add("a", nil)
+	plain.add("b", nil)
+	if parts := plain.result(); parts != nil {
+		t.Errorf("plain chunks gave parts %+v", parts)
+	}
+
+	var c partsCollector
+	c.add("", []contentPart{{Type: "text", Text: "see "}})
+	c.add("", nil)
+	c.add("this", nil)
+	c.add("", []contentPart{{Type: "image_url", ImageURL: json.RawMessage(`"https://x/y.png"`)}, {Type: "text", Text: "done"}})
+	parts := c.result()
+	if len(parts) != 3 || parts[0].Text != "see this" || parts[1].url() != "https://x/y.png" || parts[2].Text != "done" {
+		t.Errorf("collected %+v", parts)
+	}
+}
+
+func TestTurnModelContent(t *testing.T) {
+	s := &session{}
+	turns := []turn{
+		{Message: api.Message{Role: "user", Content: "hi"}},
+		{
+			Message:  api.Message{Role: "assistant", Content: "look\n[image: https://x/y.png]"},
+			turnInfo: turnInfo{Parts: []contentPart{{Type: "text", Text: "look"}, {Type: "image_url", ImageURL: json.RawMessage(`"https://x/y.png"`)}}},
+		},
+	}
+	msgs := s.turnsRequest(turns)
+	if len(msgs) != 2 || msgs[0].Content != "hi" || msgs[1].Content != "look" {
+		t.Errorf("request messages = %+v", msgs)
+	}
+}
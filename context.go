@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ollama/ollama/api"
+)
+
+const (
+	// defaultNumCtx is the server's context window when num_ctx is not set.
+	defaultNumCtx = 4096
+
+	// defaultSummarizePercent is the threshold used by "--auto-summarize-at on".
+	defaultSummarizePercent = 75
+
+	// keepRecent is how many trailing messages summarizing leaves verbatim.
+	keepRecent = 4
+
+	// contextWarnPercent is how full the context may get before a warning.
+	contextWarnPercent = 90
+)
+
+// modelContextLength returns the context length a model was trained with,
+// as reported in Show's model_info, or 0 if unknown.
+func modelContextLength(info map[string]any) int {
+	for key, v := range info {
+		if strings.HasSuffix(key, ".context_length") {
+			if n, ok := v.(float64); ok {
+				return int(n)
+			}
+		}
+	}
+	return 0
+}
+
+// warnContextBudget warns when the conversation approaches the context
+// window, since the server silently drops what does not fit.
+func (s *session) warnContextBudget() {
+	window := s.contextWindow()
+	used := estimateTokens(s.requestMessages())
+	if used*100 < window*contextWarnPercent {
+		return
+	}
+	fmt.Fprintf(ui, "%s⚠️  The conversation is ~%d tokens of a %d-token context; the oldest turns may be cut off. Try /summarize or /ctx.%s\n", Yellow, used, window, Reset)
+}
+
+// checkNumCtx warns when n is more than the model was trained for.
+func (s *session) checkNumCtx(n int) {
+	if s.maxContext > 0 && n > s.maxContext {
+		fmt.Fprintf(ui, "%s⚠️  %s supports at most %d tokens of context; larger values waste memory and may degrade output.%s\n", Yellow, s.model, s.maxContext, Reset)
+	}
+}
+
+func (s *session) cmdCtx(args []string) {
+	if len(args) != 1 {
+		source := "server default"
+		if _, ok := s.optionInt("num_ctx"); ok {
+			source = "num_ctx"
+		}
+		fmt.Fprintf(ui, "📐 Context window: %d tokens (%s). Usage: /ctx <tokens>|default\n", s.contextWindow(), source)
+		return
+	}
+	if args[0] == "default" {
+		s.setOption("num_ctx", nil)
+		fmt.Fprintf(ui, "%s📐 Using the server's default context window.%s\n", Green, Reset)
+		return
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 {
+		fmt.Fprintf(ui, "%s❌ Context size must be a positive integer, got %q%s\n", Red, args[0], Reset)
+		return
+	}
+	s.checkNumCtx(n)
+	s.setOption("num_ctx", float64(n))
+	fmt.Fprintf(ui, "%s📐 Context window set to %d tokens; the model reloads on the next request.%s\n", Green, n, Reset)
+}
+
+// estimateTokens approximates the prompt size of msgs at four characters per
+// token plus a little per-message overhead for the chat template.
+func estimateTokens(msgs []api.Message) int {
+	n := 0
+	for _, m := range msgs {
+		n += (len(m.Content)+len(m.Thinking))/4 + 4
+	}
+	return n
+}
+
+// contextWindow returns the context size the model is run with.
+func (s *session) contextWindow() int {
+	if n, ok := s.optionInt("num_ctx"); ok {
+		return n
+	}
+	return defaultNumCtx
+}
+
+// summarizeThreshold is a token count or a percentage of the context window.
+type summarizeThreshold struct {
+	value   int
+	percent bool
+}
+
+func parseSummarizeThreshold(s string) (summarizeThreshold, error) {
+	if s == "on" {
+		return summarizeThreshold{value: defaultSummarizePercent, percent: true}, nil
+	}
+	num, percent := strings.CutSuffix(s, "%")
+	v, err := strconv.Atoi(num)
+	if err != nil || v <= 0 || (percent && v > 100) {
+		return summarizeThreshold{}, fmt.Errorf("invalid threshold %q: want a token count, a percentage like 75%%, or \"on\"", s)
+	}
+	return summarizeThreshold{value: v, percent: percent}, nil
+}
+
+func (t summarizeThreshold) tokens(window int) int {
+	if t.percent {
+		return window * t.value / 100
+	}
+	return t.value
+}
+
+// maybeAutoSummarize summarizes the oldest turns once the estimated context
+// crosses the configured threshold.
+func (s *session) maybeAutoSummarize() {
+	if s.autoSummarize == nil {
+		return
+	}
+	limit := s.autoSummarize.tokens(s.contextWindow())
+	if used := estimateTokens(s.requestMessages()); used < limit {
+		return
+	}
+	n, err := s.summarizeOldest()
+	if err != nil {
+		fmt.Fprintf(ui, "%s⚠️  Auto-summarize failed:%s %v\n", Yellow, Reset, err)
+		return
+	}
+	if n > 0 {
+		fmt.Fprintf(ui, "%s🗜️  Context reached ~%d tokens; summarized %d older messages.%s\n", Dim, limit, n, Reset)
+	}
+}
+
+// summarizeOldest replaces everything between the leading system turns and the
+// last keepRecent messages with a model-written summary, returning how many
+// messages were replaced.
+func (s *session) summarizeOldest() (int, error) {
+	// The leading system turns (the system prompt and any context files)
+	// are standing context and never summarized.
+	start := 0
+	for start < len(s.messages) && s.messages[start].Role == "system" {
+		start++
+	}
+	end := len(s.messages) - keepRecent
+	if end-start < 2 {
+		return 0, nil
+	}
+
+	// A summary of incognito turns is itself kept out of saved sessions.
+	var transcript strings.Builder
+	incognito := false
+	for _, m := range s.messages[start:end] {
+		fmt.Fprintf(&transcript, "%s: %s\n\n", m.Role, m.Content)
+		incognito = incognito || m.Incognito
+	}
+	summary, err := s.complete([]api.Message{
+		{Role: "system", Content: "Summarize the following conversation in a few short paragraphs. Keep facts, decisions, names and open questions; drop pleasantries."},
+		{Role: "user", Content: transcript.String()},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	replaced := end - start
+	msgs := append([]turn{}, s.messages[:start]...)
+	msgs = append(msgs, turn{
+		Message:  api.Message{Role: "system", Content: "Summary of the earlier conversation:\n" + strings.TrimSpace(summary)},
+		turnInfo: turnInfo{Incognito: incognito},
+	})
+	s.messages = append(msgs, s.messages[end:]...)
+	return replaced, nil
+}
+
+func (s *session) cmdSummarize() {
+	before := estimateTokens(s.requestMessages())
+	n, err := s.summarizeOldest()
+	switch {
+	case err != nil:
+		fmt.Fprintf(ui, "%s❌ Summarize failed:%s %v\n", Red, Reset, err)
+	case n == 0:
+		fmt.Fprintln(ui, "🗜️  Not enough history to summarize yet.")
+	default:
+		fmt.Fprintf(ui, "%s🗜️  Summarized %d messages (~%d → ~%d tokens).%s\n", Green, n, before, estimateTokens(s.requestMessages()), Reset)
+	}
+}
+
+// loadContextFiles adds each file as a system turn after the system message,
+// labeled by its name. Files that would push the total past maxTokens are
+// skipped with a warning.
+func (s *session) loadContextFiles(paths []string, maxTokens int) {
+	total := 0
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(ui, "%s⚠️  Skipping context file:%s %v\n", Yellow, Reset, err)
+			continue
+		}
+		msg := api.Message{
+			Role:    "system",
+			Content: fmt.Sprintf("Reference file %s:\n```\n%s\n```", filepath.Base(path), strings.TrimRight(string(data), "\n")),
+		}
+		tokens := estimateTokens([]api.Message{msg})
+		if maxTokens > 0 && total+tokens > maxTokens {
+			fmt.Fprintf(ui, "%s⚠️  Skipping %s: ~%d tokens would exceed the %d-token context file cap.%s\n", Yellow, path, tokens, maxTokens, Reset)
+			continue
+		}
+		total += tokens
+		s.messages = append(s.messages, turn{Message: msg})
+		fmt.Fprintf(ui, "%s📚 Context:%s %s (~%d tokens)\n", Yellow, Reset, filepath.Base(path), tokens)
+	}
+}
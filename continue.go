@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ollama/ollama/api"
+)
+
+const (
+	// continueInstruction asks the model to pick up a cut-off response.
+	continueInstruction = "Continue exactly where you left off. Do not repeat anything you already wrote and do not add any preamble."
+
+	// overlapWindow is how much of a continuation is searched for text
+	// repeated from the end of the previous part.
+	overlapWindow = 200
+
+	// minOverlap is the shortest repeat that is trimmed, so that a short
+	// coincidental match is not mistaken for one.
+	minOverlap = 12
+)
+
+// trimOverlap drops the start of next where it repeats the end of prev.
+func trimOverlap(prev, next string) string {
+	for k := min(len(prev), len(next), overlapWindow); k >= minOverlap; k-- {
+		if strings.HasSuffix(prev, next[:k]) {
+			return next[k:]
+		}
+	}
+	return next
+}
+
+// truncated reports whether a response stopped at the length limit.
+func truncated(final *api.ChatResponse) bool {
+	return final != nil && final.DoneReason == "length"
+}
+
+// continueFrom asks for the rest of partial, a response to msgs, and returns
+// the two parts merged into one turn.
+func (s *session) continueFrom(msgs []api.Message, partial turn) (turn, *api.ChatResponse, error) {
+	req := s.chatRequest(append(msgs[:len(msgs):len(msgs)],
+		api.Message{Role: "assistant", Content: partial.modelContent(), Images: partial.Images},
+		api.Message{Role: "user", Content: continueInstruction},
+	))
+	next, final, err := s.chatStream(req, partial.Content)
+	partial.Parts = joinParts(partial, next)
+	partial.Content += next.Content
+	partial.Thinking += next.Thinking
+	partial.Images = append(partial.Images, next.Images...)
+	return partial, final, err
+}
+
+// autoContinue keeps continuing a response cut off at the length limit, up
+// to the configured number of extra parts and total size.
+func (s *session) autoContinue(msgs []api.Message, reply turn, final *api.ChatResponse) (turn, *api.ChatResponse, error) {
+	var err error
+	for part := 2; truncated(final) && part <= s.autoContinueParts+1; part++ {
+		if len(reply.Content) >= s.autoContinueMaxChars {
+			fmt.Fprintf(ui, "\n%s✂️  Reached the %d-character auto-continue cap.%s", Yellow, s.autoContinueMaxChars, Reset)
+			break
+		}
+		fmt.Fprintf(ui, "\n%s➕ Cut off at the length limit; continuing (part %d of up to %d)...%s\n", Dim, part, s.autoContinueParts+1, Reset)
+		if reply, final, err = s.continueFrom(msgs, reply); err != nil {
+			break
+		}
+	}
+	return reply, final, err
+}
+
+// cmdContinue extends the last response in place.
+func (s *session) cmdContinue() {
+	i := s.lastAssistant()
+	if i < 0 || i != len(s.messages)-1 {
+		fmt.Fprintln(ui, Yellow+"⚠️  /continue extends the last response, but there is none at the end of the conversation."+Reset)
+		return
+	}
+	msgs := s.historyRequest(i)
+	reply, final, err := s.continueFrom(msgs, s.messages[i])
+	fmt.Fprintln(out)
+	if err != nil {
+		s.reportError(err)
+	}
+	if !s.thinking.Store {
+		reply.Thinking = ""
+	}
+	s.messages[i].Message, s.messages[i].Parts = reply.Message, reply.Parts
+	if final != nil {
+		s.messages[i].Meta = final
+	}
+	if err == nil && truncated(final) {
+		fmt.Fprintln(ui, Dim+"✂️  Still cut off at the length limit; /continue again for more."+Reset)
+	}
+}
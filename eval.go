@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ollama/ollama/api"
+)
+
+// defaultSweepTemps are the temperatures /sweep-temp tries by default.
+var defaultSweepTemps = []float64{0, 0.3, 0.7, 1.0}
+
+// variant is one labeled request of a comparison run.
+type variant struct {
+	label string
+	req   *api.ChatRequest
+}
+
+// statelessRequest builds a one-off request for prompt under the given
+// system prompt, carrying none of the conversation. An empty system prompt
+// sends no system message.
+func (s *session) statelessRequest(system, prompt string) *api.ChatRequest {
+	var msgs []api.Message
+	if system != "" {
+		msgs = append(msgs, api.Message{Role: "system", Content: system})
+	}
+	return s.chatRequest(append(msgs, api.Message{Role: "user", Content: s.wrapUser(prompt)}))
+}
+
+// runVariants sends each variant in turn, streaming its labeled response,
+// and finishes with a timing table. Nothing is added to the history. The
+// runs are sequential so they never compete with each other for the model.
+func (s *session) runVariants(variants []variant) {
+	timings := make([]time.Duration, len(variants))
+	failed := make([]bool, len(variants))
+	for i, v := range variants {
+		fmt.Fprintf(ui, "\n%s━━ %s ━━%s\n", Purple, v.label, Reset)
+		start := time.Now()
+		_, _, err := s.chat(v.req)
+		timings[i] = time.Since(start)
+		fmt.Fprintln(out)
+		if err != nil {
+			failed[i] = true
+			s.reportError(err)
+		}
+	}
+
+	fmt.Fprintf(ui, "\n%s⏱️  Timings:%s\n", Yellow, Reset)
+	for i, v := range variants {
+		status := ""
+		if failed[i] {
+			status = Red + " (failed)" + Reset
+		}
+		fmt.Fprintf(ui, "  %-28s %8s%s\n", v.label, timings[i].Round(time.Millisecond), status)
+	}
+}
+
+// parseFloatList parses a comma-separated list of numbers.
+func parseFloatList(s string) ([]float64, error) {
+	var values []float64
+	for _, field := range strings.Split(s, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", field)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// cmdSweepTemp runs one prompt at several temperatures.
+func (s *session) cmdSweepTemp(args []string) {
+	temps := defaultSweepTemps
+	if len(args) > 1 && args[0] == "-t" {
+		var err error
+		if temps, err = parseFloatList(args[1]); err != nil {
+			fmt.Fprintf(ui, "%s❌ %v%s\n", Red, err, Reset)
+			return
+		}
+		args = args[2:]
+	}
+	if len(args) == 0 {
+		fmt.Fprintln(ui, "🌡️  Usage: /sweep-temp [-t 0,0.5,1] <prompt>")
+		return
+	}
+	prompt := strings.Join(args, " ")
+
+	var variants []variant
+	for _, t := range temps {
+		req := s.statelessRequest(s.systemPrompt(), prompt)
+		setRequestOption(req, "temperature", t)
+		variants = append(variants, variant{label: fmt.Sprintf("temperature %g", t), req: req})
+	}
+	s.runVariants(variants)
+}
+
+// cmdVary regenerates the answer to the last prompt once per value of one
+// option. The session's own options are left unchanged.
+func (s *session) cmdVary(args []string) {
+	if len(args) != 2 {
+		fmt.Fprintln(ui, "🎛️  Usage: /vary <option> <v1,v2,...>  (e.g. /vary top_p 0.5,0.9)")
+		return
+	}
+	name := args[0]
+	values, err := parseFloatList(args[1])
+	if err == nil {
+		for _, v := range values {
+			if err = validateOptions(map[string]any{name: v}); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(ui, "%s❌ %v%s\n", Red, err, Reset)
+		return
+	}
+	i := s.lastRole("user")
+	if i < 0 {
+		fmt.Fprintln(ui, Yellow+"⚠️  Nothing to regenerate yet — ask something first."+Reset)
+		return
+	}
+	msgs := s.historyRequest(i + 1)
+
+	var variants []variant
+	for _, v := range values {
+		req := s.chatRequest(msgs)
+		setRequestOption(req, name, v)
+		variants = append(variants, variant{label: fmt.Sprintf("%s %g", name, v), req: req})
+	}
+	s.runVariants(variants)
+}
+
+func defaultPersonasDir() string {
+	return filepath.Join(filepath.Dir(defaultConfigPath()), "personas")
+}
+
+// loadPersona reads a persona's system prompt, given as a name in the
+// personas directory (<name>.txt) or as a path to a file.
+func (s *session) loadPersona(name string) (string, error) {
+	path := filepath.Join(s.personasDir, name+".txt")
+	if strings.ContainsRune(name, os.PathSeparator) || filepath.Ext(name) != "" {
+		path = name
+	}
+	return loadSystemMessage(path)
+}
+
+// cmdPersonaCompare runs one prompt under two personas, side by side.
+func (s *session) cmdPersonaCompare(args []string) {
+	if len(args) < 3 {
+		fmt.Fprintf(ui, "🎭 Usage: /persona-compare <persona1> <persona2> <prompt>  (personas from %s)\n", s.personasDir)
+		return
+	}
+	prompt := strings.Join(args[2:], " ")
+
+	var variants []variant
+	for _, name := range args[:2] {
+		system, err := s.loadPersona(name)
+		if err != nil {
+			fmt.Fprintf(ui, "%s❌ Cannot load persona:%s %v\n", Red, Reset, err)
+			return
+		}
+		variants = append(variants, variant{label: "persona " + name, req: s.statelessRequest(system, prompt)})
+	}
+	s.runVariants(variants)
+}
+
+// probeFiller returns roughly tokens tokens of numbered filler lines.
+func probeFiller(tokens int) string {
+	var b strings.Builder
+	for i := 1; b.Len() < tokens*4; i++ {
+		fmt.Fprintf(&b, "Line %d: the daily log entry for item %d was routine and needs no action.\n", i, i)
+	}
+	return b.String()
+}
+
+// cmdProbeCtx finds how much context the model really uses: it hides a code
+// at the start of ever larger filler prompts and asks for it back at the
+// end. The first size where the code is lost, or the server fails, bounds
+// the usable context. Probes are one-off requests outside the history.
+func (s *session) cmdProbeCtx(args []string) {
+	step, limit := 2048, 4*s.contextWindow()
+	if s.maxContext > 0 {
+		limit = min(limit, s.maxContext)
+	}
+	for i, dst := range []*int{&step, &limit} {
+		if len(args) > i {
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n <= 0 {
+				fmt.Fprintln(ui, "📏 Usage: /probe-ctx [step] [max]  (tokens)")
+				return
+			}
+			*dst = n
+		}
+	}
+	if step > limit {
+		fmt.Fprintf(ui, "%s⚠️  No probe ran: the step of %d tokens is larger than the maximum of %d.%s\n", Yellow, step, limit, Reset)
+		return
+	}
+
+	fmt.Fprintf(ui, "%s📏 Probing %s from %d to %d tokens in steps of %d (context window %d).%s\n", Yellow, s.model, step, limit, step, s.contextWindow(), Reset)
+	fmt.Fprintln(ui, Dim+"   Each step is a full request over a growing prompt; this can take a while."+Reset)
+
+	const code = "PLUM-4729"
+	usable := 0
+	var failed error
+	for size := step; size <= limit; size += step {
+		req := s.chatRequest([]api.Message{{Role: "user", Content: "Remember this code: " + code + "\n\n" + probeFiller(size) +
+			"\nWhat was the code given at the very start? Reply with the code only."}})
+		req.Stream = new(bool)
+		req.Think = nil
+
+		ctx, cancel := s.requestContext()
+		start := time.Now()
+		var resp api.ChatResponse
+		err := s.client.Chat(ctx, req, func(r api.ChatResponse) error {
+			resp = r
+			return nil
+		})
+		cancel()
+		elapsed := time.Since(start).Round(time.Millisecond)
+
+		switch {
+		case err != nil:
+			failed = err
+			fmt.Fprintf(ui, "  %6d tokens  %s✗ server error%s (%v)\n", size, Red, Reset, err)
+		case strings.Contains(resp.Message.Content, code):
+			usable = size
+			fmt.Fprintf(ui, "  %6d tokens  %s✓ recalled%s  prompt %d tokens, %s\n", size, Green, Reset, resp.PromptEvalCount, elapsed)
+			continue
+		default:
+			fmt.Fprintf(ui, "  %6d tokens  %s✗ lost%s      prompt %d tokens, %s\n", size, Red, Reset, resp.PromptEvalCount, elapsed)
+		}
+		break
+	}
+
+	switch {
+	case usable == 0 && failed != nil:
+		fmt.Fprintf(ui, "%s📏 No result: the server failed at the first step of %d tokens.%s\n", Red, step, Reset)
+	case usable == 0:
+		fmt.Fprintf(ui, "%s📏 The code was lost even at %d tokens.%s\n", Red, step, Reset)
+	case failed != nil:
+		fmt.Fprintf(ui, "%s📏 Effective usable context: at least %d tokens; a server error stopped the probe before the code was lost.%s\n", Yellow, usable, Reset)
+	default:
+		fmt.Fprintf(ui, "%s📏 Effective usable context: at least %d tokens.%s\n", Green, usable, Reset)
+	}
+}
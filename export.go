@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// shareFields are the front matter fields /export-share can write, in order.
+var shareFields = []string{"title", "model", "date", "options", "language", "tool_version", "ollama_version", "command"}
+
+// validateShareFields checks that every field is one /export-share writes.
+func validateShareFields(fields []string) error {
+	for _, f := range fields {
+		if !slices.Contains(shareFields, f) {
+			return fmt.Errorf("unknown share field %q; use %s", f, strings.Join(shareFields, ", "))
+		}
+	}
+	return nil
+}
+
+// toolVersion returns the module version this binary was built from.
+func toolVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+var roleTitles = map[string]string{
+	"system":    "⚙️ System",
+	"user":      "🧑 User",
+	"assistant": "🤖 Assistant",
+	"tool":      "🔧 Tool",
+}
+
+// markdownTranscript renders the savable turns as a Markdown document, one
+// section per turn. When roles or tags are given, only turns with one of
+// those roles and one of those tags are included.
+func (s *session) markdownTranscript(roles, tags []string) string {
+	var b strings.Builder
+	for _, t := range s.persistedTurns() {
+		if len(roles) > 0 && !slices.Contains(roles, t.Role) {
+			continue
+		}
+		if len(tags) > 0 && !slices.ContainsFunc(t.Tags, func(tag string) bool { return slices.Contains(tags, tag) }) {
+			continue
+		}
+		title, ok := roleTitles[t.Role]
+		if !ok {
+			title = t.Role
+		}
+		fmt.Fprintf(&b, "### %s\n\n%s\n\n", title, strings.TrimSpace(t.Content))
+	}
+	return b.String()
+}
+
+// reproduceCommand returns a command line that starts a session with the
+// current settings.
+func (s *session) reproduceCommand() string {
+	args := []string{"ollama-terminal"}
+	if s.lang != "" {
+		args = append(args, "--lang", shellQuote(s.lang))
+	}
+	if len(s.options) > 0 {
+		b, _ := json.Marshal(s.options)
+		args = append(args, "--options", shellQuote(string(b)))
+	}
+	if s.userPrefix != "" {
+		args = append(args, "--user-prefix", shellQuote(s.userPrefix))
+	}
+	if s.userSuffix != "" {
+		args = append(args, "--user-suffix", shellQuote(s.userSuffix))
+	}
+	return strings.Join(args, " ")
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// frontMatter renders the requested fields as a YAML front matter block.
+// Strings are written as double-quoted scalars, which YAML reads like Go
+// string literals.
+func (s *session) frontMatter(fields []string, title string) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	for _, field := range fields {
+		switch field {
+		case "title":
+			fmt.Fprintf(&b, "title: %s\n", strconv.Quote(title))
+		case "model":
+			fmt.Fprintf(&b, "model: %s\n", strconv.Quote(s.model))
+		case "date":
+			fmt.Fprintf(&b, "date: %s\n", time.Now().Format(time.RFC3339))
+		case "options":
+			if len(s.options) == 0 {
+				b.WriteString("options: {}\n")
+				continue
+			}
+			b.WriteString("options:\n")
+			for _, key := range slices.Sorted(maps.Keys(s.options)) {
+				v, _ := json.Marshal(s.options[key])
+				fmt.Fprintf(&b, "  %s: %s\n", key, v)
+			}
+		case "language":
+			if s.lang != "" {
+				fmt.Fprintf(&b, "language: %s\n", strconv.Quote(languageName(s.lang)))
+			}
+		case "tool_version":
+			fmt.Fprintf(&b, "tool_version: %s\n", strconv.Quote(toolVersion()))
+		case "ollama_version":
+			fmt.Fprintf(&b, "ollama_version: %s\n", strconv.Quote(s.serverVersion))
+		case "command":
+			fmt.Fprintf(&b, "command: %s\n", strconv.Quote(s.reproduceCommand()))
+		}
+	}
+	b.WriteString("---\n\n")
+	return b.String()
+}
+
+// exportArgs holds the parsed arguments of /export and /export-share.
+type exportArgs struct {
+	path  string
+	roles []string
+	tags  []string
+}
+
+// parseExportArgs reads the file name and the options, which may come before
+// or after it. --roles and --tags take comma-separated lists; with --roles
+// the system prompt is only exported when "system" is among them.
+func parseExportArgs(args []string) (exportArgs, error) {
+	var a exportArgs
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if (name == "--roles" || name == "--tags") && !hasValue {
+			if i++; i == len(args) {
+				return a, fmt.Errorf("%s needs a comma-separated list", name)
+			}
+			value = args[i]
+		}
+		switch {
+		case name == "--tags":
+			a.tags = append(a.tags, strings.Split(value, ",")...)
+		case name == "--roles":
+			for _, role := range strings.Split(value, ",") {
+				if _, ok := roleTitles[role]; !ok {
+					return a, fmt.Errorf("unknown role %q; use %s", role, strings.Join(slices.Sorted(maps.Keys(roleTitles)), ", "))
+				}
+				a.roles = append(a.roles, role)
+			}
+		case strings.HasPrefix(args[i], "--"):
+			return a, fmt.Errorf("unknown option %s", name)
+		case a.path != "":
+			return a, fmt.Errorf("only one file can be given")
+		default:
+			a.path = args[i]
+		}
+	}
+	if a.path == "" {
+		return a, fmt.Errorf("no file given")
+	}
+	return a, nil
+}
+
+func (s *session) cmdExport(args []string, share bool) {
+	a, err := parseExportArgs(args)
+	if err != nil {
+		fmt.Fprintf(ui, "%s❌ %v%s\n", Red, err, Reset)
+		if share {
+			fmt.Fprintln(ui, "📤 Usage: /export-share <file.md> [--roles r1,r2] [--tags t1,t2]")
+		} else {
+			fmt.Fprintln(ui, "📤 Usage: /export <file.md> [--roles r1,r2] [--tags t1,t2]")
+		}
+		return
+	}
+	path := a.path
+
+	var doc strings.Builder
+	if share {
+		doc.WriteString(s.frontMatter(s.shareFields, "Conversation with "+s.model))
+	}
+	doc.WriteString(s.markdownTranscript(a.roles, a.tags))
+
+	if err := os.WriteFile(path, []byte(doc.String()), 0o644); err != nil {
+		fmt.Fprintf(ui, "%s❌ Export failed:%s %v\n", Red, Reset, err)
+		return
+	}
+	fmt.Fprintf(ui, "%s📤 Exported to%s %s\n", Green, Reset, path)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/ollama/ollama/api"
+	"github.com/ollama/ollama/types/model"
+)
+
+// fimSeparator splits the /fim argument into the code before and after the
+// gap.
+const fimSeparator = "---"
+
+// fimEscapes lets a one-line /fim argument contain newlines and tabs.
+var fimEscapes = strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\\`, `\`)
+
+// cmdFIM asks a code model to fill the gap between a prefix and a suffix,
+// using the generate endpoint's suffix field, and prints only the
+// completion. "-m <model>" picks the model; without it the chat model is
+// used. It uses no conversation history and adds nothing to it.
+func (s *session) cmdFIM(rest string) {
+	modelName := s.model
+	if after, ok := strings.CutPrefix(rest, "-m "); ok {
+		modelName, rest, _ = strings.Cut(strings.TrimLeft(after, " "), " ")
+	}
+	prefix, suffix, ok := strings.Cut(rest, fimSeparator)
+	if !ok || strings.TrimSpace(prefix) == "" {
+		fmt.Fprintln(ui, `🧩 Usage: /fim [-m <model>] <prefix> --- <suffix>  (\n and \t for newlines and tabs)`)
+		return
+	}
+
+	ctx, cancel := s.requestContext()
+	defer cancel()
+	capabilities := s.capabilities
+	if modelName != s.model {
+		showRes, err := s.client.Show(ctx, &api.ShowRequest{Model: modelName})
+		if err != nil {
+			s.reportError(err)
+			return
+		}
+		capabilities = showRes.Capabilities
+	}
+	if !slices.Contains(capabilities, model.CapabilityInsert) {
+		fmt.Fprintf(ui, "%s❌ %s does not support fill-in-the-middle (no %q capability); pass a code model such as qwen2.5-coder or codellama:code with -m.%s\n", Red, modelName, model.CapabilityInsert, Reset)
+		return
+	}
+	req := &api.GenerateRequest{
+		Model:   modelName,
+		Prompt:  fimEscapes.Replace(strings.TrimSuffix(prefix, " ")),
+		Suffix:  fimEscapes.Replace(strings.TrimPrefix(suffix, " ")),
+		Options: maps.Clone(s.options),
+	}
+
+	if s.debug {
+		fmt.Fprintf(ui, "%s🐞 Sending prefix %q and suffix %q%s\n", Dim, req.Prompt, req.Suffix, Reset)
+	}
+	err := s.client.Generate(ctx, req, func(resp api.GenerateResponse) error {
+		writeResponse(resp.Response)
+		return nil
+	})
+	fmt.Fprintln(out)
+	if err != nil {
+		s.reportError(err)
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ollama/ollama/api"
+)
+
+// historyPreview is how many characters of each turn /history shows.
+const historyPreview = 70
+
+// preview returns the first line of text, shortened to n characters.
+func preview(text string, n int) string {
+	text = strings.TrimSpace(text)
+	line, _, more := strings.Cut(text, "\n")
+	if utf8.RuneCountInString(line) > n {
+		line, more = string([]rune(line)[:n]), true
+	}
+	if more {
+		line += "…"
+	}
+	return line
+}
+
+func roleColor(role string) string {
+	switch role {
+	case "system":
+		return Yellow
+	case "user":
+		return Green
+	case "assistant":
+		return Blue
+	}
+	return Purple
+}
+
+// cmdHistory lists the conversation with one numbered line per turn, or
+// only the turns with the given tag.
+func (s *session) cmdHistory(tag string) {
+	if tag == "" {
+		fmt.Fprintf(ui, "%s📜 History:%s\n", Yellow, Reset)
+	} else {
+		fmt.Fprintf(ui, "%s📜 History tagged %s:%s\n", Yellow, tag, Reset)
+	}
+	for i, t := range s.messages {
+		if tag != "" && !slices.Contains(t.Tags, tag) {
+			continue
+		}
+		marks := ""
+		for _, tag := range t.Tags {
+			marks += " " + Cyan + "#" + tag + Reset
+		}
+		if t.TimeLimited {
+			marks += " ⏳"
+		}
+		if t.Incognito {
+			marks += " 🕶️"
+		}
+		fmt.Fprintf(ui, "  %s%3d%s %s%-9s%s %s%s\n", Dim, i, Reset, roleColor(t.Role), t.Role, Reset, preview(t.Content, historyPreview), marks)
+	}
+}
+
+// cmdInsert appends a hand-written turn without asking the model for a
+// response, e.g. to build few-shot examples.
+func (s *session) cmdInsert(rest string) {
+	role, text, _ := strings.Cut(rest, " ")
+	text = strings.TrimSpace(text)
+	if len(text) >= 2 && (text[0] == '"' || text[0] == '\'') && text[len(text)-1] == text[0] {
+		text = text[1 : len(text)-1]
+	}
+	if role != "user" && role != "assistant" && role != "system" {
+		fmt.Fprintln(ui, `✍️  Usage: /insert user|assistant|system "text"`)
+		return
+	}
+	if text == "" {
+		fmt.Fprintln(ui, Red+"❌ The inserted turn needs some text."+Reset)
+		return
+	}
+
+	prev := "system"
+	if len(s.messages) > 0 {
+		prev = s.messages[len(s.messages)-1].Role
+	}
+	if role != "system" && prev == role {
+		fmt.Fprintf(ui, "%s⚠️  Two %s turns in a row; some models handle this poorly.%s\n", Yellow, role, Reset)
+	}
+	if role == "assistant" && prev == "system" {
+		fmt.Fprintf(ui, "%s⚠️  An assistant turn with no user turn before it; some models handle this poorly.%s\n", Yellow, Reset)
+	}
+	s.messages = append(s.messages, turn{
+		Message:  api.Message{Role: role, Content: text},
+		turnInfo: turnInfo{Incognito: s.incognito},
+	})
+	fmt.Fprintf(ui, "%s✍️  Inserted %s turn.%s\n", Green, role, Reset)
+	s.cmdHistory("")
+}
+
+// recentResponses returns the indexes of the last n assistant turns, oldest
+// first.
+func (s *session) recentResponses(n int) []int {
+	var idx []int
+	for i := len(s.messages) - 1; i >= 0 && len(idx) < n; i-- {
+		if s.messages[i].Role == "assistant" {
+			idx = append(idx, i)
+		}
+	}
+	slices.Reverse(idx)
+	return idx
+}
+
+// cmdRecall lists the most recent responses, or reprints one. Responses are
+// numbered by their position among all responses in the conversation, so a
+// number keeps pointing at the same answer as new ones arrive.
+func (s *session) cmdRecall(args []string) {
+	recent := s.recentResponses(s.recallSize)
+	if len(recent) == 0 {
+		fmt.Fprintln(ui, Yellow+"⚠️  No response yet."+Reset)
+		return
+	}
+	total := 0
+	for _, t := range s.messages {
+		if t.Role == "assistant" {
+			total++
+		}
+	}
+	first := total - len(recent) + 1
+
+	if len(args) == 0 {
+		fmt.Fprintf(ui, "%s🔁 Recent responses:%s\n", Yellow, Reset)
+		for k, i := range recent {
+			fmt.Fprintf(ui, "  %s%3d%s  %s\n", Cyan, first+k, Reset, preview(s.messages[i].Content, historyPreview))
+		}
+		return
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || len(args) > 1 {
+		fmt.Fprintln(ui, "🔁 Usage: /recall [N]")
+		return
+	}
+	if n < first || n > total {
+		fmt.Fprintf(ui, "%s❌ Response %d is not among the last %d (%d–%d).%s\n", Red, n, len(recent), first, total, Reset)
+		return
+	}
+	fmt.Fprintln(out, s.messages[recent[n-first]].Content)
+}
+
+// tagPattern is what a /tag label may contain.
+var tagPattern = regexp.MustCompile(`^[\p{L}\p{N}_.-]+$`)
+
+// cmdTag labels a turn, by default the last one, so that /history and the
+// exports can pick it out later.
+func (s *session) cmdTag(args []string) {
+	if len(args) == 0 || len(args) > 2 || !tagPattern.MatchString(args[0]) {
+		fmt.Fprintln(ui, "🏷️  Usage: /tag <label> [n]  (labels use letters, digits, '_', '.' and '-')")
+		return
+	}
+	label := args[0]
+	i := len(s.messages) - 1
+	if len(args) == 2 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 || n >= len(s.messages) {
+			fmt.Fprintf(ui, "%s❌ No turn %s; /history shows the numbers.%s\n", Red, args[1], Reset)
+			return
+		}
+		i = n
+	}
+	if i < 0 {
+		fmt.Fprintln(ui, Yellow+"⚠️  There are no turns to tag yet."+Reset)
+		return
+	}
+	t := &s.messages[i]
+	if slices.Contains(t.Tags, label) {
+		fmt.Fprintf(ui, "🏷️  Turn %d is already tagged %s.\n", i, label)
+		return
+	}
+	t.Tags = append(t.Tags, label)
+	fmt.Fprintf(ui, "%s🏷️  Tagged turn %d (%s) %s.%s\n", Green, i, t.Role, label, Reset)
+}
+
+// cmdTags lists every tag with the number of turns carrying it.
+func (s *session) cmdTags() {
+	counts := map[string]int{}
+	for _, t := range s.messages {
+		for _, tag := range t.Tags {
+			counts[tag]++
+		}
+	}
+	if len(counts) == 0 {
+		fmt.Fprintln(ui, "🏷️  No tags yet. Usage: /tag <label> [n]")
+		return
+	}
+	fmt.Fprintf(ui, "%s🏷️  Tags:%s\n", Yellow, Reset)
+	for _, tag := range slices.Sorted(maps.Keys(counts)) {
+		fmt.Fprintf(ui, "  %s#%-16s%s %d turn(s)\n", Cyan, tag, Reset, counts[tag])
+	}
+}
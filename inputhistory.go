@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxInputMatches is how many matches /search-input lists, most recent last.
+const maxInputMatches = 20
+
+// inputEntry is one line typed at the prompt.
+type inputEntry struct {
+	at   time.Time
+	text string
+}
+
+func defaultInputHistoryPath() string {
+	return filepath.Join(filepath.Dir(defaultConfigPath()), "input_history")
+}
+
+// recordInput appends a typed line to the input history file, one
+// "<RFC 3339 time>\t<text>" line per entry. Nothing is recorded while
+// incognito.
+func (s *session) recordInput(text string) {
+	if s.incognito || s.inputHistory == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(s.inputHistory), 0o755); err != nil {
+		return
+	}
+	f, err := os.OpenFile(s.inputHistory, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintf(f, "%s\t%s\n", time.Now().Format(time.RFC3339), text)
+}
+
+// readInputHistory returns the entries of the input history file. A missing
+// file has no entries.
+func readInputHistory(path string) ([]inputEntry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []inputEntry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for sc.Scan() {
+		stamp, text, ok := strings.Cut(sc.Text(), "\t")
+		if !ok {
+			continue
+		}
+		at, _ := time.Parse(time.RFC3339, stamp)
+		entries = append(entries, inputEntry{at: at, text: text})
+	}
+	return entries, sc.Err()
+}
+
+// cmdSearchInput lists past prompts containing term and offers to resend
+// one of them.
+func (s *session) cmdSearchInput(term string) {
+	if term == "" {
+		fmt.Fprintln(ui, "🔍 Usage: /search-input <term>")
+		return
+	}
+	entries, err := readInputHistory(s.inputHistory)
+	if err != nil {
+		fmt.Fprintf(ui, "%s❌ Cannot read input history:%s %v\n", Red, Reset, err)
+		return
+	}
+	var matches []inputEntry
+	for _, e := range entries {
+		if strings.Contains(strings.ToLower(e.text), strings.ToLower(term)) && !strings.HasPrefix(e.text, "/search-input") {
+			matches = append(matches, e)
+		}
+	}
+	if len(matches) == 0 {
+		fmt.Fprintf(ui, "🔍 No past input matches %q.\n", term)
+		return
+	}
+	if len(matches) > maxInputMatches {
+		matches = matches[len(matches)-maxInputMatches:]
+	}
+
+	for i, e := range matches {
+		fmt.Fprintf(ui, "  %s%2d%s %s%s%s  %s\n", Cyan, i+1, Reset, Dim, e.at.Local().Format("2006-01-02 15:04"), Reset, e.text)
+	}
+	fmt.Fprint(ui, Yellow+"↩️  Resend which? (number, Enter to cancel): "+Reset)
+	line, err := s.in.ReadString('\n')
+	if err != nil {
+		return
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || n < 1 || n > len(matches) {
+		return
+	}
+
+	text := matches[n-1].text
+	fmt.Fprintf(ui, "%s📝 %s%s\n", Dim, text, Reset)
+	s.recordInput(text)
+	if strings.HasPrefix(text, "/") {
+		s.handleCommand(text)
+		return
+	}
+	s.send(text)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"os/exec"
+	"strings"
+)
+
+// langAliases maps code block languages to the name formatters are keyed by.
+var langAliases = map[string]string{
+	"golang": "go",
+	"py":     "python",
+	"js":     "javascript",
+	"ts":     "typescript",
+	"sh":     "bash",
+	"shell":  "bash",
+	"rs":     "rust",
+}
+
+// lintResult is the outcome of checking one code block.
+type lintResult struct {
+	ok        bool   // the formatter accepted the code
+	formatted string // the formatter's output when ok
+	message   string // why it failed, or why it was skipped
+	skipped   bool
+}
+
+// formatGo formats Go code with the gofmt rules. Unlike the gofmt command it
+// also accepts fragments such as a single function or a list of statements,
+// which is how most answers show Go.
+func formatGo(code string) lintResult {
+	out, err := format.Source([]byte(code))
+	if err != nil {
+		return lintResult{message: err.Error()}
+	}
+	return lintResult{ok: true, formatted: string(out)}
+}
+
+// lintBlock checks a code block with the formatter configured for its
+// language: an external command reading the code on stdin and writing the
+// formatted code to stdout. Go is handled in process unless a command is
+// configured for it; commands only run with --allow-shell, as for /pipe.
+func (s *session) lintBlock(b codeBlock) lintResult {
+	lang := strings.ToLower(b.lang)
+	if alias, ok := langAliases[lang]; ok {
+		lang = alias
+	}
+	command, ok := s.formatters[lang]
+	switch {
+	case !ok && lang == "go":
+		return formatGo(b.code)
+	case !ok:
+		return lintResult{skipped: true, message: "no formatter for " + orNone(lang)}
+	case !s.allowShell:
+		return lintResult{skipped: true, message: "the " + lang + " formatter needs --allow-shell"}
+	}
+	if name, _, _ := strings.Cut(strings.TrimSpace(command), " "); name != "" {
+		if _, err := exec.LookPath(name); err != nil {
+			return lintResult{skipped: true, message: name + " is not installed"}
+		}
+	}
+	output, code, err := runShell(command, b.code)
+	switch {
+	case err != nil:
+		return lintResult{message: err.Error()}
+	case code != 0:
+		msg, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+		return lintResult{message: fmt.Sprintf("exit status %d: %s", code, msg)}
+	}
+	return lintResult{ok: true, formatted: string(output)}
+}
+
+// orNone names the language of a block that has none.
+func orNone(lang string) string {
+	if lang == "" {
+		return "plain"
+	}
+	return lang
+}
+
+// lintCode checks every code block of a response and reports one line per
+// block, with the formatted code when show is set and it differs.
+func (s *session) lintCode(content string, show bool) {
+	blocks := extractCodeBlocks(content)
+	if len(blocks) == 0 {
+		return
+	}
+	fmt.Fprintf(ui, "%s🧹 Code check:%s\n", Yellow, Reset)
+	for i, b := range blocks {
+		r := s.lintBlock(b)
+		label := fmt.Sprintf("block %d (%s)", i+1, orNone(b.lang))
+		switch {
+		case r.skipped:
+			fmt.Fprintf(ui, "  %-22s %s– %s%s\n", label, Dim, r.message, Reset)
+		case !r.ok:
+			fmt.Fprintf(ui, "  %-22s %s✗ %s%s\n", label, Red, r.message, Reset)
+		case strings.TrimSpace(r.formatted) == strings.TrimSpace(b.code):
+			fmt.Fprintf(ui, "  %-22s %s✓ well-formed%s\n", label, Green, Reset)
+		default:
+			fmt.Fprintf(ui, "  %-22s %s✓ well-formed%s, but not formatted\n", label, Green, Reset)
+			if show {
+				fmt.Fprintln(out, renderMarkdown("```"+b.lang+"\n"+strings.TrimRight(r.formatted, "\n")+"\n```"))
+			}
+		}
+	}
+}
+
+// cmdLint checks the code blocks of the last response; -f shows the
+// formatted version of blocks that are not formatted.
+func (s *session) cmdLint(args []string) {
+	i := s.lastAssistant()
+	if i < 0 {
+		fmt.Fprintln(ui, Yellow+"⚠️  No response yet."+Reset)
+		return
+	}
+	if len(extractCodeBlocks(s.messages[i].Content)) == 0 {
+		fmt.Fprintln(ui, Yellow+"⚠️  The last response has no code block."+Reset)
+		return
+	}
+	s.lintCode(s.messages[i].Content, len(args) > 0 && args[0] == "-f")
+}
@@ -1,56 +1,179 @@
 package main
 
 import (
-	"bufio"
 	"context"
+	"flag"
 	"fmt"
 	"log"
+	"maps"
+	"net/http"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ollama/ollama/api"
+	"github.com/ollama/ollama/envconfig"
 )
 
 const (
-	Reset  = "\033[0m"
-	Green  = "\033[32m"
-	Blue   = "\033[34m"
-	Cyan   = "\033[36m"
-	Yellow = "\033[33m"
-	Red    = "\033[31m"
-	Purple = "\033[35m"
+	Reset     = "\033[0m"
+	Green     = "\033[32m"
+	Blue      = "\033[34m"
+	Cyan      = "\033[36m"
+	Yellow    = "\033[33m"
+	Red       = "\033[31m"
+	Purple    = "\033[35m"
+	Dim       = "\033[2m"
+	Bold      = "\033[1m"
+	Underline = "\033[4m"
 )
 
+// systemFile holds the system prompt, which may use {{placeholders}}.
+const systemFile = "system.txt"
+
+// defaultSystemMessage is used when the system file is missing or empty.
+const defaultSystemMessage = "You are a helpful assistant."
+
+// loadSystemMessage reads a system prompt file. A file with nothing but
+// whitespace is an error, so that an empty system turn is never sent.
 func loadSystemMessage(filename string) (string, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return "", err
 	}
-	return strings.TrimSpace(string(data)), nil
+	text := strings.TrimSpace(string(data))
+	if text == "" {
+		return "", fmt.Errorf("%s is empty", filename)
+	}
+	return text, nil
 }
 
+// NewOllamaClient returns a client for the server in OLLAMA_HOST. Its
+// transport accepts chat responses with content given as typed parts.
 func NewOllamaClient() *api.Client {
-	client, err := api.ClientFromEnvironment()
-	if err != nil {
-		log.Fatalln(Red+"[ERROR]"+Reset, "Failed to create Ollama client:", err)
-	}
-	return client
+	return api.NewClient(envconfig.Host(), &http.Client{Transport: partsTransport{base: http.DefaultTransport}})
 }
 
 func main() {
+	setupOutput()
+
+	cfg := defaultConfig()
+	configPath := configPathFromArgs(os.Args[1:])
+	sources := configSources{}
+	if err := loadConfig(configPath, &cfg, sources); err != nil {
+		log.Fatalln(Red+"[ERROR]"+Reset, "Failed to load config:", err)
+	}
+
+	flag.String("config", configPath, "path to the JSON config file")
+	printCfg := flag.Bool("print-config", false, "print the effective configuration and where each setting came from, then exit")
+	flag.StringVar(&cfg.Lang, "lang", cfg.Lang, "respond in the given language (e.g. es, fr, German)")
+	flag.DurationVar((*time.Duration)(&cfg.Timeout), "timeout", time.Duration(cfg.Timeout), "maximum time to wait for a complete response (0 for no limit)")
+	flag.DurationVar((*time.Duration)(&cfg.FirstChunkTimeout), "first-chunk-timeout", time.Duration(cfg.FirstChunkTimeout), "cancel a response when its first output takes longer than this (0 disables)")
+	flag.DurationVar((*time.Duration)(&cfg.IdleTimeout), "idle-timeout", time.Duration(cfg.IdleTimeout), "cancel a response when no output arrives for this long (0 disables)")
+	flag.StringVar(&cfg.UserPrefix, "user-prefix", cfg.UserPrefix, "text added before every user message when sent")
+	flag.StringVar(&cfg.UserSuffix, "user-suffix", cfg.UserSuffix, "text added after every user message when sent")
+	flag.StringVar(&cfg.SessionsDir, "sessions-dir", cfg.SessionsDir, "directory for saved sessions")
+	flag.Var(&listFlag{values: &cfg.ContextFiles}, "context-file", "load a reference `file` as standing context (repeatable)")
+	flag.IntVar(&cfg.ContextMaxTokens, "context-max-tokens", cfg.ContextMaxTokens, "approximate token cap for all context files together")
+	flag.StringVar(&cfg.AutoSummarizeAt, "auto-summarize-at", cfg.AutoSummarizeAt, "summarize old turns past `N` tokens, a percent of the context (80%), or \"on\" for "+strconv.Itoa(defaultSummarizePercent)+"%")
+	flag.Var(&listFlag{values: &cfg.ShareFields}, "share-field", "front matter `field` for /export-share (repeatable; one of "+strings.Join(shareFields, ", ")+")")
+	flag.BoolVar(&cfg.AllowShell, "allow-shell", cfg.AllowShell, "allow commands that run external programs, such as /pipe")
+	flag.BoolVar(&cfg.AutoArchive, "auto-archive", cfg.AutoArchive, "on exit, save the conversation under a model-suggested title in the archive folder")
+	flag.BoolVar(&cfg.Incognito, "incognito", cfg.Incognito, "start in incognito mode: turns are never written to saved sessions")
+	flag.BoolVar(&cfg.NoSystem, "no-system", cfg.NoSystem, "send no system message (unless /lang or /always add instructions)")
+	flag.StringVar(&cfg.PersonasDir, "personas-dir", cfg.PersonasDir, "directory of persona system prompts (<name>.txt)")
+	flag.IntVar(&cfg.RecallSize, "recall-size", cfg.RecallSize, "how many recent responses /recall lists")
+	flag.StringVar(&cfg.InputHistory, "input-history", cfg.InputHistory, "file that records typed prompts for /search-input (empty disables)")
+	flag.StringVar(&cfg.Thinking.Level, "think", cfg.Thinking.Level, "thinking level: "+strings.Join(thinkingLevels, ", "))
+	flag.BoolVar(&cfg.Thinking.Show, "show-thinking", cfg.Thinking.Show, "display the model's reasoning while it streams")
+	flag.StringVar(&cfg.Thinking.Render, "thinking-render", cfg.Thinking.Render, "how shown reasoning is displayed: "+strings.Join(thinkingRenders, ", "))
+	flag.BoolFunc("quiet-thinking", "display reasoning as a line of progress dots (--show-thinking --thinking-render=dots)", cfg.Thinking.setQuiet)
+	flag.IntVar(&cfg.Thinking.DotTokens, "thinking-dot-tokens", cfg.Thinking.DotTokens, "thinking tokens per progress dot")
+	flag.BoolVar(&cfg.Thinking.Store, "store-thinking", cfg.Thinking.Store, "keep reasoning in the history and saved sessions")
+	flag.BoolVar(&cfg.TrimLeadingNewlines, "trim-leading-newlines", cfg.TrimLeadingNewlines, "strip newlines from the start of each response (=false to keep them)")
+	flag.StringVar(&cfg.FormatSchema, "format-schema", cfg.FormatSchema, "constrain responses to a JSON schema (inline JSON or a `file`) and validate them")
+	flag.BoolVar(&cfg.SchemaRetry, "schema-retry", cfg.SchemaRetry, "re-request once with the errors when a response fails schema validation")
+	flag.IntVar(&cfg.AutoContinue, "auto-continue", cfg.AutoContinue, "continue a response cut off at the length limit up to `N` times")
+	flag.IntVar(&cfg.AutoContinueMaxChars, "auto-continue-max-chars", cfg.AutoContinueMaxChars, "stop auto-continuing once a response is this long")
+	flag.BoolVar(&cfg.LintCode, "lint-code", cfg.LintCode, "check code blocks in responses with a formatter (gofmt rules for Go)")
+	flag.BoolVar(&cfg.LintShow, "lint-show", cfg.LintShow, "with --lint-code, show the formatted version of unformatted blocks")
+	flag.IntVar(&cfg.RetryEmpty, "retry-empty", cfg.RetryEmpty, "re-send up to `N` times when the response is empty")
+	flag.BoolVar(&cfg.Debug, "debug", cfg.Debug, "print the messages exactly as sent")
+	var jsonOptions optionsFlag
+	flag.StringVar(&cfg.Preset, "preset", cfg.Preset, "apply a named option preset from the config file")
+	flag.Var(&jsonOptions, "options", "model options as a JSON object, e.g. '{\"temperature\":0.3}'")
+	temperature := flag.Float64("temperature", 0, "sampling temperature (overrides --options)")
+	seed := flag.Int("seed", 0, "random seed (overrides --options)")
+	numCtx := flag.Int("num-ctx", 0, "context window in tokens (overrides --options)")
+	flag.Parse()
+
+	if err := cfg.Thinking.validate(); err != nil {
+		log.Fatalln(Red+"[ERROR]"+Reset, err)
+	}
+	if err := validateShareFields(cfg.ShareFields); err != nil {
+		log.Fatalln(Red+"[ERROR]"+Reset, err)
+	}
+
+	// Options resolve as config file, then the preset, then --options, then
+	// the individual option flags.
+	if err := validateOptions(cfg.Options); err != nil {
+		log.Fatalln(Red+"[ERROR]"+Reset, "Invalid options in config:", err)
+	}
+	for name, opts := range cfg.Presets {
+		if err := validateOptions(opts); err != nil {
+			log.Fatalf("%s Invalid options in preset %q: %v", Red+"[ERROR]"+Reset, name, err)
+		}
+	}
+	flagOptions := map[string]any{}
+	maps.Copy(flagOptions, jsonOptions)
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "temperature":
+			flagOptions["temperature"] = *temperature
+		case "seed":
+			flagOptions["seed"] = float64(*seed)
+		case "num-ctx":
+			flagOptions["num_ctx"] = float64(*numCtx)
+		}
+		sources.addFlag(f.Name)
+	})
+	preset, ok := cfg.Presets[cfg.Preset]
+	if cfg.Preset != "" && !ok {
+		log.Fatalf("%s Unknown preset %q; the config defines: %s", Red+"[ERROR]"+Reset, cfg.Preset, strings.Join(slices.Sorted(maps.Keys(cfg.Presets)), ", "))
+	}
+	configOptions := cfg.Options
+	cfg.Options = map[string]any{}
+	for _, layer := range []map[string]any{configOptions, preset, flagOptions} {
+		maps.Copy(cfg.Options, layer)
+	}
+	for k := range preset {
+		sources["options."+k] = "preset"
+	}
+	for k := range flagOptions {
+		sources["options."+k] = "flag"
+	}
+	if *printCfg {
+		printConfig(out, outColor, configPath, cfg, sources)
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
 	client := NewOllamaClient()
 
-	systemMsg, err := loadSystemMessage("system.txt")
-	if err != nil {
-		log.Printf("Could not load system message: %v", err)
-		systemMsg = "You are a helpful assistant." // fallback
+	var systemMsg string
+	if !cfg.NoSystem {
+		var err error
+		if systemMsg, err = loadSystemMessage(systemFile); err != nil {
+			log.Printf("Could not load system message: %v; using the default", err)
+			systemMsg = defaultSystemMessage
+		}
 	}
 
-	fmt.Println(Cyan + "🔌 Connecting to Ollama..." + Reset)
+	fmt.Fprintln(ui, Cyan+"🔌 Connecting to Ollama..."+Reset)
 	if err := client.Heartbeat(ctx); err != nil {
 		fmt.Fprintf(os.Stderr, "\n%s❌  OLLAMA CONNECTION FAILED%s\n", Red, Reset)
 		fmt.Fprintf(os.Stderr, "────────────────────────────────────\n")
@@ -60,13 +183,16 @@ func main() {
 		fmt.Fprintf(os.Stderr, "────────────────────────────────────\n\n")
 		os.Exit(1)
 	}
-	fmt.Println(Green + "✅ Connected successfully!" + Reset)
+	fmt.Fprintln(ui, Green+"✅ Connected successfully!"+Reset)
 
-	clientVersion, err := client.Version(ctx)
+	serverVersion, err := client.Version(ctx)
 	if err != nil {
-		log.Fatalln(Red+"[ERROR]"+Reset, "Failed to get version:", err)
+		log.Printf("Could not get the Ollama version: %v", err)
+		serverVersion = "unknown"
+	} else if _, ok := parseVersion(serverVersion); !ok {
+		log.Printf("Unrecognized Ollama version %q; assuming a recent release", serverVersion)
 	}
-	fmt.Printf("%s📋 Client Version:%s %s\n\n", Yellow, Reset, clientVersion)
+	fmt.Fprintf(ui, "%s📋 Ollama Version:%s %s\n\n", Yellow, Reset, serverVersion)
 
 	listRes, err := client.List(ctx)
 	if err != nil {
@@ -76,17 +202,17 @@ func main() {
 	defaultModel := "gpt-oss:20b"
 	embeddingModel := "nomic-embed-text"
 
-	fmt.Printf("%s📦 Available Models:%s\n", Yellow, Reset)
+	fmt.Fprintf(ui, "%s📦 Available Models:%s\n", Yellow, Reset)
 	for i, m := range listRes.Models {
 		prefix := "  "
 		if m.Name == defaultModel {
 			prefix = "  " + Green + "★" + Reset + " "
 		}
-		fmt.Printf("%s%d: %s%s%s\n", prefix, i, Cyan, m.Name, Reset)
+		fmt.Fprintf(ui, "%s%d: %s%s%s\n", prefix, i, Cyan, m.Name, Reset)
 	}
 
-	fmt.Printf("\n%s💬 Default Chat Model:%s %s\n", Yellow, Reset, defaultModel)
-	fmt.Printf("%s🧩 Embedding Model:%s %s\n", Yellow, Reset, embeddingModel)
+	fmt.Fprintf(ui, "\n%s💬 Default Chat Model:%s %s\n", Yellow, Reset, defaultModel)
+	fmt.Fprintf(ui, "%s🧩 Embedding Model:%s %s\n", Yellow, Reset, embeddingModel)
 
 	// Show model capabilities
 	showReq := &api.ShowRequest{Model: defaultModel}
@@ -94,25 +220,37 @@ func main() {
 	if err != nil {
 		log.Fatalln(Red+"[ERROR]"+Reset, "Show failed:", err)
 	}
-	fmt.Printf("\n%s⚙️  Capabilities of %s:%s\n", Yellow, defaultModel, Reset)
+	fmt.Fprintf(ui, "\n%s⚙️  Capabilities of %s:%s\n", Yellow, defaultModel, Reset)
 	for _, cap := range showRes.Capabilities {
-		fmt.Printf("  - %s\n", cap)
+		fmt.Fprintf(ui, "  - %s\n", cap)
+	}
+
+	s := newSession(client, defaultModel, systemMsg)
+	s.capabilities = showRes.Capabilities
+	s.serverVersion = serverVersion
+	s.maxContext = modelContextLength(showRes.ModelInfo)
+	s.embedModel = embeddingModel
+	s.applyConfig(cfg)
+	s.config, s.configPath, s.configSources = cfg, configPath, sources
+	s.configOptions, s.flagOptions = configOptions, flagOptions
+	s.systemFile = systemFile
+	s.setSystem(systemMsg)
+	if cfg.FormatSchema != "" {
+		if s.formatSchema, s.schema, err = loadSchema(cfg.FormatSchema); err != nil {
+			log.Fatalln(Red+"[ERROR]"+Reset, "Failed to load --format-schema:", err)
+		}
+	}
+	if n, ok := s.optionInt("num_ctx"); ok {
+		s.checkNumCtx(n)
 	}
+	s.loadContextFiles(cfg.ContextFiles, cfg.ContextMaxTokens)
 
 	// Chat loop
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Println("\n" + Blue + "🗨️  Start chatting with your AI (type 'exit' to quit)" + Reset)
-
-	// --- 🟢 New: Conversation History ---
-	messages := make([]api.Message, 0)
-	messages = append(messages, api.Message{
-		Role:    "system",
-		Content: systemMsg,
-	})
+	fmt.Fprintln(ui, "\n"+Blue+"🗨️  Start chatting with your AI (type 'exit' to quit, '/help' for commands)"+Reset)
 
 	for {
-		fmt.Print("\n" + Green + "📝 You: " + Reset)
-		text, err := reader.ReadString('\n')
+		fmt.Fprint(ui, "\n"+s.prompt())
+		text, err := s.in.ReadString('\n')
 		if err != nil {
 			// ... (error handling)
 			continue
@@ -121,62 +259,19 @@ func main() {
 		if text == "" {
 			continue
 		}
+		s.recordInput(text)
 		if strings.ToLower(text) == "exit" || text == "quit" {
-			fmt.Println(Blue + "👋 Goodbye! Stay safe." + Reset)
+			if cfg.AutoArchive {
+				s.autoArchive()
+			}
+			fmt.Fprintln(ui, Blue+"👋 Goodbye! Stay safe."+Reset)
 			break
 		}
-
-		// --- 🟢 New: Add the user's message to history ---
-		messages = append(messages, api.Message{
-			Role:    "user",
-			Content: text,
-		})
-
-		longerCtx, cancel := context.WithTimeout(context.Background(), time.Second*30)
-		// No defer cancel() here, it should be called at the end of the loop iteration
-
-		var fullResponse strings.Builder
-		thinkingDone := false
-		think := &api.ThinkValue{Value: "low"}
-
-		// --- 🟢 New: Use ChatRequest and Chat endpoint ---
-		chatReq := &api.ChatRequest{
-			Model:    defaultModel,
-			Messages: messages, // Send the full message history
-			Think:    think,
-		}
-
-		err = client.Chat(longerCtx, chatReq, func(resp api.ChatResponse) error {
-			// --- Handle Thinking (optional, but good to keep) ---
-			if resp.DoneReason == "" && resp.Message.Content == "" && !thinkingDone {
-				// Your existing logic for thinking...
-			}
-
-			if resp.Message.Thinking != "" && !thinkingDone {
-				// Your existing logic for finalizing thinking...
-			}
-
-			// --- Stream Response ---
-			if resp.Message.Content != "" {
-				fmt.Print(Blue + resp.Message.Content + Reset)
-				fullResponse.WriteString(resp.Message.Content)
-			}
-			return nil
-		})
-
-		// 🟢 New: Add the model's response to history
-		messages = append(messages, api.Message{
-			Role:    "assistant",
-			Content: fullResponse.String(),
-		})
-
-		if err != nil {
-			fmt.Printf("\n%s❌ Generation failed:%s %v%s\n", Red, Reset, err, Reset)
-			// Optional: you might want to remove the last user message from history on error
+		if strings.HasPrefix(text, "/") {
+			s.handleCommand(text)
+			continue
 		}
 
-		// Final newline after response
-		fmt.Println()
-		cancel() // Call cancel at the end of the loop
+		s.send(text)
 	}
 }
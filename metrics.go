@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"time"
+	"unicode/utf8"
+)
+
+// tokensPerSecond is a rate for display, or 0 when there is no duration.
+func tokensPerSecond(count int, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(count) / d.Seconds()
+}
+
+// cmdMeta prints the server-reported metadata of the last response.
+func (s *session) cmdMeta() {
+	i := s.lastAssistant()
+	if i < 0 || s.messages[i].Meta == nil {
+		fmt.Fprintln(ui, Yellow+"⚠️  No response metadata yet."+Reset)
+		return
+	}
+	m := s.messages[i].Meta
+	doneReason := m.DoneReason
+	if doneReason == "" {
+		doneReason = "-"
+	}
+
+	fmt.Fprintf(ui, "%s🔎 Last Response Metadata:%s\n", Yellow, Reset)
+	fmt.Fprintf(ui, "  %-22s %s\n", "model", m.Model)
+	fmt.Fprintf(ui, "  %-22s %s\n", "created_at", m.CreatedAt.Local().Format(time.RFC3339))
+	fmt.Fprintf(ui, "  %-22s %s\n", "done_reason", doneReason)
+	fmt.Fprintf(ui, "  %-22s %s\n", "total_duration", m.TotalDuration)
+	fmt.Fprintf(ui, "  %-22s %s\n", "load_duration", m.LoadDuration)
+	fmt.Fprintf(ui, "  %-22s %d\n", "prompt_eval_count", m.PromptEvalCount)
+	fmt.Fprintf(ui, "  %-22s %s (%.1f tok/s)\n", "prompt_eval_duration", m.PromptEvalDuration, tokensPerSecond(m.PromptEvalCount, m.PromptEvalDuration))
+	fmt.Fprintf(ui, "  %-22s %d\n", "eval_count", m.EvalCount)
+	fmt.Fprintf(ui, "  %-22s %s (%.1f tok/s)\n", "eval_duration", m.EvalDuration, tokensPerSecond(m.EvalCount, m.EvalDuration))
+}
+
+// cmdCountMessages prints how many turns each role has and their average
+// content length.
+func (s *session) cmdCountMessages() {
+	roles := []string{"system", "user", "assistant", "tool"}
+	counts := map[string]int{}
+	chars := map[string]int{}
+	for _, m := range s.messages {
+		if _, ok := counts[m.Role]; !ok && !slices.Contains(roles, m.Role) {
+			roles = append(roles, m.Role)
+		}
+		counts[m.Role]++
+		chars[m.Role] += utf8.RuneCountInString(m.Content)
+	}
+
+	fmt.Fprintf(ui, "%s🔢 Messages by Role:%s\n", Yellow, Reset)
+	fmt.Fprintf(ui, "  %-10s %6s %12s\n", "role", "turns", "avg chars")
+	for _, role := range roles {
+		avg := 0
+		if counts[role] > 0 {
+			avg = chars[role] / counts[role]
+		}
+		fmt.Fprintf(ui, "  %-10s %6d %12d\n", role, counts[role], avg)
+	}
+	fmt.Fprintf(ui, "  %-10s %6d\n", "total", len(s.messages))
+}
+
+// reloadThreshold is the load time above which a response is counted as
+// having loaded the model. A model that is already in memory still reports
+// a few milliseconds of load time.
+const reloadThreshold = 100 * time.Millisecond
+
+// cmdProfileSession breaks down where generation time went across every
+// response of the session that has server metrics.
+func (s *session) cmdProfileSession() {
+	var n, reloads int
+	var total, load, prompt, eval time.Duration
+	var promptTokens, evalTokens int
+	for _, t := range s.messages {
+		if t.Role != "assistant" || t.Meta == nil {
+			continue
+		}
+		m := t.Meta.Metrics
+		n++
+		total += m.TotalDuration
+		load += m.LoadDuration
+		prompt += m.PromptEvalDuration
+		eval += m.EvalDuration
+		promptTokens += m.PromptEvalCount
+		evalTokens += m.EvalCount
+		if m.LoadDuration > reloadThreshold {
+			reloads++
+		}
+	}
+	if n == 0 || total <= 0 {
+		fmt.Fprintln(ui, Yellow+"⚠️  No response metrics yet."+Reset)
+		return
+	}
+
+	fmt.Fprintf(ui, "%s⏱️  Session Profile%s (%d responses)\n", Yellow, Reset, n)
+	fmt.Fprintf(ui, "  %-12s %12s %12s %7s\n", "phase", "total", "average", "share")
+	row := func(name string, d time.Duration) {
+		fmt.Fprintf(ui, "  %-12s %12s %12s %6.1f%%\n", name, d.Round(time.Millisecond), (d / time.Duration(n)).Round(time.Millisecond), 100*d.Seconds()/total.Seconds())
+	}
+	row("load", load)
+	row("prompt eval", prompt)
+	row("eval", eval)
+	row("other", max(total-load-prompt-eval, 0))
+	row("total", total)
+	fmt.Fprintf(ui, "  %-12s %d of %d responses\n", "reloads", reloads, n)
+	fmt.Fprintf(ui, "  %-12s %.1f tok/s prompt, %.1f tok/s generation\n", "throughput", tokensPerSecond(promptTokens, prompt), tokensPerSecond(evalTokens, eval))
+
+	switch {
+	case reloads > 1 && load > eval:
+		fmt.Fprintln(ui, Dim+"💡 Model loading dominates; a longer keep-alive (OLLAMA_KEEP_ALIVE) would help."+Reset)
+	case eval >= prompt && eval >= load:
+		fmt.Fprintln(ui, Dim+"💡 Generation itself is the bottleneck; a smaller model or shorter answers would help."+Reset)
+	case prompt > eval:
+		fmt.Fprintln(ui, Dim+"💡 Prompt processing dominates; a shorter history (/summarize) would help."+Reset)
+	}
+}
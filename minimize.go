@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// minimizeThreshold is the default word similarity to the original
+	// response that counts as reproducing it.
+	minimizeThreshold = 0.5
+
+	// maxMinimizeRuns caps the requests one /minimize makes.
+	maxMinimizeRuns = 40
+)
+
+// wordSimilarity is the Jaccard similarity of the sets of lower-cased words
+// in a and b.
+func wordSimilarity(a, b string) float64 {
+	words := func(s string) map[string]bool {
+		set := map[string]bool{}
+		for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			set[w] = true
+		}
+		return set
+	}
+	wa, wb := words(a), words(b)
+	if len(wa) == 0 && len(wb) == 0 {
+		return 1
+	}
+	shared := 0
+	for w := range wa {
+		if wb[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wa)+len(wb)-shared)
+}
+
+// ddmin reduces items to a smaller list for which test still holds, by
+// repeatedly trying to drop chunks of it, halving the chunk size when no
+// chunk can go.
+func ddmin(items []int, test func([]int) bool) []int {
+	n := 2
+	for len(items) >= 2 {
+		chunk := (len(items) + n - 1) / n
+		reduced := false
+		for start := 0; start < len(items); start += chunk {
+			rest := append(slices.Clone(items[:start]), items[min(start+chunk, len(items)):]...)
+			if test(rest) {
+				items, n, reduced = rest, max(n-1, 2), true
+				break
+			}
+		}
+		if !reduced {
+			if n >= len(items) {
+				break
+			}
+			n = min(2*n, len(items))
+		}
+	}
+	if len(items) == 1 && test(nil) {
+		items = nil
+	}
+	return items
+}
+
+// cmdMinimize finds a small subset of the earlier turns under which the last
+// prompt still gets a response like the last one: one containing the given
+// text, or else one at least as similar as the threshold. The history is
+// replaced with that minimal case; /minimize restore brings back the
+// original.
+func (s *session) cmdMinimize(args []string) {
+	if len(args) > 0 && args[0] == "restore" {
+		if s.preMinimize == nil {
+			fmt.Fprintln(ui, Yellow+"⚠️  There is no minimized history to undo."+Reset)
+			return
+		}
+		s.messages, s.preMinimize = s.preMinimize, nil
+		fmt.Fprintf(ui, "%s✂️  Restored the original %d turns.%s\n", Green, len(s.messages), Reset)
+		return
+	}
+	threshold := minimizeThreshold
+	if len(args) > 1 && args[0] == "-t" {
+		t, err := strconv.ParseFloat(args[1], 64)
+		if err != nil || t <= 0 || t > 1 {
+			fmt.Fprintln(ui, "✂️  Usage: /minimize [-t similarity 0–1] [text the bad response contains] | /minimize restore")
+			return
+		}
+		threshold, args = t, args[2:]
+	}
+	marker := strings.Join(args, " ")
+
+	a := s.lastAssistant()
+	u := -1
+	for i := a - 1; i >= 0; i-- {
+		if s.messages[i].Role == "user" {
+			u = i
+			break
+		}
+	}
+	if a < 0 || u < 0 {
+		fmt.Fprintln(ui, Yellow+"⚠️  /minimize needs a prompt and the response to reproduce."+Reset)
+		return
+	}
+	reference := s.messages[a].Content
+	if marker != "" && !strings.Contains(strings.ToLower(reference), strings.ToLower(marker)) {
+		fmt.Fprintf(ui, "%s⚠️  The last response does not contain %q.%s\n", Yellow, marker, Reset)
+		return
+	}
+
+	fixed := 0
+	for fixed < u && s.messages[fixed].Role == "system" {
+		fixed++
+	}
+	var candidates []int
+	for i := fixed; i < u; i++ {
+		candidates = append(candidates, i)
+	}
+	build := func(kept []int) []turn {
+		turns := slices.Clone(s.messages[:fixed])
+		for _, i := range kept {
+			turns = append(turns, s.messages[i])
+		}
+		return append(turns, s.messages[u])
+	}
+
+	if _, ok := s.options["seed"]; !ok {
+		fmt.Fprintln(ui, Dim+"💡 No seed is set; with --seed or a fixed seed option the runs are more comparable."+Reset)
+	}
+	target := "similarity ≥ " + strconv.FormatFloat(threshold, 'f', -1, 64)
+	if marker != "" {
+		target = fmt.Sprintf("contains %q", marker)
+	}
+	fmt.Fprintf(ui, "%s✂️  Minimizing %d earlier turns (target: %s, at most %d runs)%s\n", Yellow, len(candidates), target, maxMinimizeRuns, Reset)
+
+	runs := 0
+	test := func(kept []int) bool {
+		if runs == maxMinimizeRuns {
+			return false
+		}
+		runs++
+		reply, err := s.complete(s.turnsRequest(build(kept)))
+		if err != nil {
+			fmt.Fprintf(ui, "  run %2d  %2d turns  %s✗ %v%s\n", runs, len(kept), Red, err, Reset)
+			return false
+		}
+		sim := wordSimilarity(reply, reference)
+		ok := sim >= threshold
+		if marker != "" {
+			ok = strings.Contains(strings.ToLower(reply), strings.ToLower(marker))
+		}
+		mark := Red + "✗" + Reset
+		if ok {
+			mark = Green + "✓" + Reset
+		}
+		fmt.Fprintf(ui, "  run %2d  %2d turns  %s similarity %.2f\n", runs, len(kept), mark, sim)
+		return ok
+	}
+
+	if !test(candidates) {
+		fmt.Fprintln(ui, Yellow+"⚠️  The full history did not reproduce the response; try a lower -t, a marker text or a fixed seed."+Reset)
+		return
+	}
+	kept := ddmin(candidates, test)
+	if runs == maxMinimizeRuns {
+		fmt.Fprintln(ui, Dim+"   Run limit reached; the result may not be minimal."+Reset)
+	}
+
+	s.preMinimize = slices.Clone(s.messages)
+	s.messages = append(build(kept), s.messages[a])
+	fmt.Fprintf(ui, "%s✂️  Reduced %d earlier turns to %d in %d runs.%s\n", Green, len(candidates), len(kept), runs, Reset)
+	fmt.Fprintln(ui, Dim+"   /history to review, /export to share, /minimize restore to undo."+Reset)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ollama/ollama/api"
+)
+
+// optionNames returns the keys accepted in ChatRequest.Options, taken from the
+// JSON tags of api.Options.
+func optionNames() []string {
+	var names []string
+	for _, f := range reflect.VisibleFields(reflect.TypeOf(api.Options{})) {
+		if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name != "" {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// validateOptions checks that every key is a known option and that its
+// value has the right type.
+func validateOptions(opts map[string]any) error {
+	known := optionNames()
+	for key := range opts {
+		if !slices.Contains(known, key) {
+			return fmt.Errorf("unknown option %q", key)
+		}
+	}
+	var o api.Options
+	return o.FromMap(opts)
+}
+
+// optionsFlag is a flag.Value holding model options given as a JSON object.
+type optionsFlag map[string]any
+
+func (f *optionsFlag) String() string {
+	if f == nil || len(*f) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(*f)
+	return string(b)
+}
+
+func (f *optionsFlag) Set(value string) error {
+	var opts map[string]any
+	if err := json.Unmarshal([]byte(value), &opts); err != nil {
+		return fmt.Errorf("options must be a JSON object: %w", err)
+	}
+	if err := validateOptions(opts); err != nil {
+		return err
+	}
+	if *f == nil {
+		*f = optionsFlag{}
+	}
+	maps.Copy(*f, opts)
+	return nil
+}
+
+func (s *session) cmdOptions() {
+	fmt.Fprintf(ui, "%s⚙️  Model Options:%s\n", Yellow, Reset)
+	if len(s.options) == 0 {
+		fmt.Fprintln(ui, "  (model defaults)")
+		return
+	}
+	for _, key := range slices.Sorted(maps.Keys(s.options)) {
+		fmt.Fprintf(ui, "  %s%-18s%s %v\n", Cyan, key, Reset, s.options[key])
+	}
+}
+
+// setOption sets a model option for subsequent requests, or removes it when
+// value is nil. Numbers are stored as float64, as decoded from JSON.
+func (s *session) setOption(key string, value any) {
+	if value == nil {
+		delete(s.options, key)
+		return
+	}
+	if s.options == nil {
+		s.options = map[string]any{}
+	}
+	s.options[key] = value
+}
+
+// setRequestOption sets an option on a request built by chatRequest, whose
+// Options map is the request's own copy.
+func setRequestOption(req *api.ChatRequest, key string, value any) {
+	if req.Options == nil {
+		req.Options = map[string]any{}
+	}
+	req.Options[key] = value
+}
+
+// optionInt returns an integer option and whether it is set.
+func (s *session) optionInt(key string) (int, bool) {
+	switch v := s.options[key].(type) {
+	case float64:
+		return int(v), true
+	case int:
+		return v, true
+	}
+	return 0, false
+}
+
+func (s *session) cmdGPU(args []string) {
+	if len(args) != 1 {
+		if n, ok := s.optionInt("num_gpu"); ok {
+			fmt.Fprintf(ui, "🎛️  num_gpu is %d. Usage: /gpu <layers>|auto\n", n)
+		} else {
+			fmt.Fprintln(ui, "🎛️  num_gpu is auto. Usage: /gpu <layers>|auto")
+		}
+		return
+	}
+	if args[0] == "auto" {
+		s.setOption("num_gpu", nil)
+		fmt.Fprintln(ui, Green+"🎛️  GPU offload back to auto; the model reloads on the next request."+Reset)
+		return
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 0 {
+		fmt.Fprintf(ui, "%s❌ Layers must be a non-negative integer or auto, got %q%s\n", Red, args[0], Reset)
+		return
+	}
+	s.setOption("num_gpu", float64(n))
+	fmt.Fprintf(ui, "%s🎛️  Offloading %d layers to the GPU; the model reloads on the next request.%s\n", Green, n, Reset)
+}
+
+// usePreset switches the option preset. Options the old preset set go back
+// to their config file values, and options given on the command line are
+// never overridden.
+func (s *session) usePreset(name string) {
+	for key := range s.presets[s.preset] {
+		if _, ok := s.flagOptions[key]; ok {
+			continue
+		}
+		s.setOption(key, s.configOptions[key])
+	}
+	for key, value := range s.presets[name] {
+		if _, ok := s.flagOptions[key]; !ok {
+			s.setOption(key, value)
+		}
+	}
+	s.preset = name
+}
+
+func (s *session) cmdPreset(args []string) {
+	if len(args) == 0 {
+		if len(s.presets) == 0 {
+			fmt.Fprintln(ui, "🎚️  No presets defined; add them under \"presets\" in the config file.")
+			return
+		}
+		fmt.Fprintf(ui, "%s🎚️  Presets:%s\n", Yellow, Reset)
+		for _, name := range slices.Sorted(maps.Keys(s.presets)) {
+			marker := "  "
+			if name == s.preset {
+				marker = Green + "★ " + Reset
+			}
+			b, _ := json.Marshal(s.presets[name])
+			fmt.Fprintf(ui, "  %s%s%-14s%s %s\n", marker, Cyan, name, Reset, b)
+		}
+		return
+	}
+	name := args[0]
+	if name == "off" {
+		s.usePreset("")
+		fmt.Fprintln(ui, Green+"🎚️  Preset cleared; options are back to the config file values."+Reset)
+		return
+	}
+	if _, ok := s.presets[name]; !ok {
+		fmt.Fprintf(ui, "%s❌ Unknown preset %q (type /preset to list them)%s\n", Red, name, Reset)
+		return
+	}
+	s.usePreset(name)
+	fmt.Fprintf(ui, "%s🎚️  Using preset %s.%s\n", Green, name, Reset)
+	var kept []string
+	for key := range s.presets[name] {
+		if _, ok := s.flagOptions[key]; ok {
+			kept = append(kept, key)
+		}
+	}
+	if len(kept) > 0 {
+		slices.Sort(kept)
+		fmt.Fprintf(ui, "%s   Kept command line values for %s.%s\n", Dim, strings.Join(kept, ", "), Reset)
+	}
+}
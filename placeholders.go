@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"os/user"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// placeholder matches {{name}} in a system prompt.
+var placeholder = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// systemVariables returns the values available to system prompt
+// placeholders: the built-in date, time, user, model and cwd, plus the
+// variables from the config file, which may override them.
+func (s *session) systemVariables() map[string]string {
+	vars := map[string]string{
+		"date":  time.Now().Format("2006-01-02"),
+		"time":  time.Now().Format("15:04"),
+		"model": s.model,
+	}
+	if u, err := user.Current(); err == nil {
+		vars["user"] = u.Username
+	} else if name := os.Getenv("USER"); name != "" {
+		vars["user"] = name
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		vars["cwd"] = cwd
+	}
+	maps.Copy(vars, s.variables)
+	return vars
+}
+
+// expandPlaceholders substitutes {{name}} placeholders from vars. Unknown
+// placeholders are left as written and returned.
+func expandPlaceholders(text string, vars map[string]string) (string, []string) {
+	var unknown []string
+	expanded := placeholder.ReplaceAllStringFunc(text, func(m string) string {
+		name := placeholder.FindStringSubmatch(m)[1]
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		if !slices.Contains(unknown, name) {
+			unknown = append(unknown, name)
+		}
+		return m
+	})
+	return expanded, unknown
+}
+
+// setSystem makes raw, with its placeholders expanded, the system prompt.
+func (s *session) setSystem(raw string) {
+	expanded, unknown := expandPlaceholders(raw, s.systemVariables())
+	for _, name := range unknown {
+		fmt.Fprintf(ui, "%s⚠️  Unknown placeholder {{%s}} in the system prompt; left as is.%s\n", Yellow, name, Reset)
+	}
+	s.system = expanded
+	for i := range s.messages {
+		if s.messages[i].BaseSystem {
+			s.messages[i].Content = expanded
+		}
+	}
+}
+
+// cmdSystem shows the effective system prompt, or with "reload" reads the
+// system file again and re-expands its placeholders.
+func (s *session) cmdSystem(args []string) {
+	if len(args) == 0 {
+		prompt := s.systemPrompt()
+		if prompt == "" {
+			prompt = "(none; no system message is sent)"
+		}
+		fmt.Fprintf(ui, "%s⚙️  System Prompt:%s\n%s\n", Yellow, Reset, prompt)
+		return
+	}
+	if args[0] != "reload" {
+		fmt.Fprintln(ui, "⚙️  Usage: /system [reload]")
+		return
+	}
+	raw, err := loadSystemMessage(s.systemFile)
+	if err != nil {
+		fmt.Fprintf(ui, "%s❌ Cannot reload the system prompt:%s %v\n", Red, Reset, err)
+		return
+	}
+	s.setSystem(raw)
+	fmt.Fprintf(ui, "%s⚙️  Reloaded the system prompt from %s.%s\n", Green, s.systemFile, Reset)
+}
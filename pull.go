@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/ollama/ollama/api"
+)
+
+// progressBarWidth is the number of cells in the pull progress bar.
+const progressBarWidth = 30
+
+func progressBar(completed, total int64) string {
+	filled := 0
+	if total > 0 {
+		filled = int(completed * progressBarWidth / total)
+	}
+	// Servers occasionally report more completed than the total.
+	filled = min(max(filled, 0), progressBarWidth)
+	return "[" + strings.Repeat("█", filled) + strings.Repeat("░", progressBarWidth-filled) + "]"
+}
+
+func shortDigest(digest string) string {
+	digest = strings.TrimPrefix(digest, "sha256:")
+	if len(digest) > 12 {
+		digest = digest[:12]
+	}
+	return digest
+}
+
+// cmdPull downloads a model with a progress bar per layer. Ctrl+C cancels
+// the request but not the program; the server keeps the partial layers, so
+// pulling again resumes where it stopped.
+func (s *session) cmdPull(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(ui, "⬇️  Usage: /pull <model>")
+		return
+	}
+	name := args[0]
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	seen := map[string]bool{}
+	current := ""
+	err := s.client.Pull(ctx, &api.PullRequest{Model: name}, func(p api.ProgressResponse) error {
+		if p.Digest == "" || p.Total == 0 {
+			if current != "" {
+				fmt.Fprintln(ui)
+				current = ""
+			}
+			fmt.Fprintf(ui, "%s⬇️  %s%s\n", Dim, p.Status, Reset)
+			return nil
+		}
+
+		if !seen[p.Digest] {
+			seen[p.Digest] = true
+			if current != "" {
+				fmt.Fprintln(ui)
+			}
+			current = p.Digest
+			switch {
+			case p.Completed >= p.Total:
+				fmt.Fprintf(ui, "%s✓ %s already downloaded (%s)%s", Green, shortDigest(p.Digest), formatBytes(p.Total), Reset)
+				return nil
+			case p.Completed > 0:
+				fmt.Fprintf(ui, "%s↻ resuming %s at %d%%%s\n", Yellow, shortDigest(p.Digest), p.Completed*100/p.Total, Reset)
+			}
+		}
+		if p.Digest != current {
+			return nil
+		}
+		line := fmt.Sprintf("  %s %s %3d%% %s / %s", shortDigest(p.Digest), progressBar(p.Completed, p.Total),
+			p.Completed*100/p.Total, formatBytes(p.Completed), formatBytes(p.Total))
+		if uiTerminal {
+			fmt.Fprint(ui, "\r\033[K"+line)
+		} else if p.Completed >= p.Total {
+			fmt.Fprint(ui, line)
+		}
+		return nil
+	})
+	if current != "" {
+		fmt.Fprintln(ui)
+	}
+
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		fmt.Fprintf(ui, "%s⏸️  Pull of %s interrupted; run /pull %s again to resume.%s\n", Yellow, name, name, Reset)
+	case err != nil:
+		fmt.Fprintf(ui, "%s❌ Pull failed:%s %v\n", Red, Reset, err)
+	default:
+		fmt.Fprintf(ui, "%s✅ Pulled %s%s\n", Green, name, Reset)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	inlineCode = regexp.MustCompile("`([^`]+)`")
+	boldText   = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	heading    = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	bullet     = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	link       = regexp.MustCompile(`\[([^\]]+)\]\((\S+?)\)`)
+
+	// leadingLink and linkPrefix match a complete link, and the start of one
+	// that may still be completed, at the start of streamed text.
+	leadingLink = regexp.MustCompile(`^\[([^\]\n]+)\]\((\S+?)\)`)
+	linkPrefix  = regexp.MustCompile(`^\[[^\]\n]*(\](\([^\s)]*)?)?$`)
+)
+
+// maxLinkHold bounds how much streamed text is held back waiting for a link
+// to close.
+const maxLinkHold = 2048
+
+// renderMarkdown styles Markdown for the terminal: headings, bullets, bold,
+// inline code and fenced code blocks. It works line by line and leaves
+// anything it does not recognise as typed. Without color it returns the
+// text unchanged.
+func renderMarkdown(text string) string {
+	if !outColor {
+		return text
+	}
+	var b strings.Builder
+	inCode := false
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			inCode = !inCode
+			b.WriteString(Dim + line + Reset)
+		case inCode:
+			b.WriteString(Cyan + line + Reset)
+		case heading.MatchString(line):
+			m := heading.FindStringSubmatch(line)
+			b.WriteString(Bold + Purple + m[2] + Reset)
+		case bullet.MatchString(line):
+			m := bullet.FindStringSubmatch(line)
+			b.WriteString(m[1] + Yellow + "• " + Reset + renderInline(m[2]))
+		default:
+			b.WriteString(renderInline(line))
+		}
+	}
+	return b.String()
+}
+
+// renderInline styles links, bold text and inline code within one line.
+// Links become OSC 8 hyperlinks where the terminal supports them, and show
+// their URL after the text elsewhere.
+func renderInline(line string) string {
+	line = link.ReplaceAllStringFunc(line, func(m string) string {
+		sub := link.FindStringSubmatch(m)
+		text, url := sub[1], sub[2]
+		if outHyperlinks {
+			return hyperlink(text, url)
+		}
+		return Underline + text + Reset + Dim + " (" + url + ")" + Reset
+	})
+	line = inlineCode.ReplaceAllString(line, Cyan+"$1"+Reset)
+	return boldText.ReplaceAllString(line, Bold+"$1"+Reset)
+}
+
+// hyperlink formats an OSC 8 hyperlink to url showing text.
+func hyperlink(text, url string) string {
+	return "\033]8;;" + url + "\033\\" + Underline + text + Reset + "\033]8;;\033\\"
+}
+
+// linkStream writes streamed response text, turning Markdown links into
+// hyperlinks as they complete where the terminal supports them. Only text
+// from a '[' that may still become a link is held back, until the link
+// closes, the line ends or maxLinkHold is reached; flush writes what is left
+// at the end of the response. Elsewhere text passes straight through.
+type linkStream struct {
+	held string
+}
+
+func (l *linkStream) write(text string) {
+	if !outHyperlinks {
+		writeResponse(text)
+		return
+	}
+	text, l.held = l.held+text, ""
+	for text != "" {
+		i := strings.IndexByte(text, '[')
+		if i < 0 {
+			writeResponse(text)
+			return
+		}
+		if i > 0 {
+			writeResponse(text[:i])
+			text = text[i:]
+		}
+		if m := leadingLink.FindStringSubmatch(text); m != nil {
+			fmt.Fprint(out, hyperlink(m[1], m[2]))
+			text = text[len(m[0]):]
+			continue
+		}
+		if len(text) < maxLinkHold && linkPrefix.MatchString(text) {
+			l.held = text
+			return
+		}
+		writeResponse("[")
+		text = text[1:]
+	}
+}
+
+func (l *linkStream) flush() {
+	if l.held != "" {
+		writeResponse(l.held)
+		l.held = ""
+	}
+}
+
+// cmdLastResponse reprints the last response, verbatim or rendered. History
+// always holds the raw Markdown.
+func (s *session) cmdLastResponse(rendered bool) {
+	i := s.lastAssistant()
+	if i < 0 {
+		fmt.Fprintln(ui, Yellow+"⚠️  No response yet."+Reset)
+		return
+	}
+	content := s.messages[i].Content
+	if rendered {
+		content = renderMarkdown(content)
+	}
+	fmt.Fprintln(out, content)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLinkStream(t *testing.T) {
+	savedOut, savedColor, savedLinks := out, outColor, outHyperlinks
+	defer func() { out, outColor, outHyperlinks = savedOut, savedColor, savedLinks }()
+	outColor, outHyperlinks = false, true
+
+	tests := []struct {
+		name   string
+		chunks []string
+		want   string
+	}{
+		{"plain", []string{"no ", "links"}, "no links"},
+		{"split link", []string{"see [the", " docs](https://x", ".y/z) now"}, "see " + hyperlink("the docs", "https://x.y/z") + " now"},
+		{"not a link", []string{"a [b", "] c\n[d"}, "a [b] c\n[d"},
+		{"line break", []string{"[a", "\nb](u)"}, "[a\nb](u)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			out = &b
+			var l linkStream
+			for _, c := range tt.chunks {
+				l.write(c)
+			}
+			l.flush()
+			if b.String() != tt.want {
+				t.Errorf("got %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
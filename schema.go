@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// jsonSchema is the subset of JSON Schema that responses are validated
+// against: type, properties, required, additionalProperties, items, enum,
+// minimum and maximum. Other keywords are sent to the server, which uses
+// them to constrain generation, but are not checked here.
+type jsonSchema struct {
+	Type                 any                    `json:"type"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	Enum                 []any                  `json:"enum"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+}
+
+// loadSchema reads a schema given inline as JSON or as a path to a file.
+func loadSchema(arg string) (json.RawMessage, *jsonSchema, error) {
+	raw := []byte(arg)
+	if !strings.HasPrefix(strings.TrimSpace(arg), "{") {
+		var err error
+		if raw, err = os.ReadFile(arg); err != nil {
+			return nil, nil, err
+		}
+	}
+	var schema jsonSchema
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return nil, nil, fmt.Errorf("schema is not valid JSON: %w", err)
+	}
+	return json.RawMessage(raw), &schema, nil
+}
+
+// validateJSON parses text and checks it against schema, returning every
+// violation found.
+func validateJSON(text string, schema *jsonSchema) []string {
+	var v any
+	if err := json.Unmarshal([]byte(strings.TrimSpace(text)), &v); err != nil {
+		return []string{"not valid JSON: " + err.Error()}
+	}
+	var errs []string
+	schema.check("$", v, &errs)
+	return errs
+}
+
+func (sc *jsonSchema) check(path string, v any, errs *[]string) {
+	if sc == nil {
+		return
+	}
+	fail := func(format string, a ...any) {
+		*errs = append(*errs, path+": "+fmt.Sprintf(format, a...))
+	}
+
+	if types := sc.types(); len(types) > 0 && !slices.ContainsFunc(types, func(t string) bool { return jsonTypeMatches(t, v) }) {
+		fail("expected %s, got %s", strings.Join(types, " or "), jsonTypeOf(v))
+		return
+	}
+	if len(sc.Enum) > 0 && !slices.ContainsFunc(sc.Enum, func(e any) bool { return fmt.Sprint(e) == fmt.Sprint(v) }) {
+		fail("%v is not one of %v", v, sc.Enum)
+	}
+
+	switch v := v.(type) {
+	case float64:
+		if sc.Minimum != nil && v < *sc.Minimum {
+			fail("%g is less than the minimum %g", v, *sc.Minimum)
+		}
+		if sc.Maximum != nil && v > *sc.Maximum {
+			fail("%g is more than the maximum %g", v, *sc.Maximum)
+		}
+	case map[string]any:
+		for _, key := range sc.Required {
+			if _, ok := v[key]; !ok {
+				fail("missing required property %q", key)
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if prop, ok := sc.Properties[key]; ok {
+				prop.check(path+"."+key, v[key], errs)
+			} else if sc.AdditionalProperties != nil && !*sc.AdditionalProperties {
+				fail("unexpected property %q", key)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			sc.Items.check(fmt.Sprintf("%s[%d]", path, i), item, errs)
+		}
+	}
+}
+
+// types returns the allowed types, which the schema gives as one name or a
+// list of names.
+func (sc *jsonSchema) types() []string {
+	switch t := sc.Type.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		var types []string
+		for _, name := range t {
+			if s, ok := name.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func jsonTypeMatches(t string, v any) bool {
+	if t == "integer" {
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	}
+	return t == jsonTypeOf(v)
+}
+
+func jsonTypeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// reportSchemaErrors prints validation failures and reports whether there
+// were any.
+func reportSchemaErrors(errs []string) bool {
+	if len(errs) == 0 {
+		fmt.Fprintln(ui, Green+"✅ Response matches the schema."+Reset)
+		return false
+	}
+	fmt.Fprintf(ui, "%s❌ Response does not match the schema:%s\n", Red, Reset)
+	for _, e := range errs {
+		fmt.Fprintf(ui, "  - %s\n", e)
+	}
+	return true
+}
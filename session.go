@@ -0,0 +1,643 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ollama/ollama/api"
+	"github.com/ollama/ollama/types/model"
+)
+
+// session holds the conversation state shared by the chat loop and the
+// slash commands.
+type session struct {
+	client *api.Client
+	in     *bufio.Reader
+	model  string
+	system string
+
+	// systemFile is where the system prompt is reloaded from, and
+	// variables are the config's values for its placeholders.
+	systemFile string
+	variables  map[string]string
+
+	// embedModel is the model used for embeddings.
+	embedModel string
+
+	messages []turn
+
+	// capabilities are those advertised by the model, as reported by Show.
+	capabilities []model.Capability
+
+	// serverVersion is the Ollama version reported at startup.
+	serverVersion string
+
+	// maxContext is the context length the model was trained with, or 0.
+	maxContext int
+
+	// attachments are files queued by /attach for the next user message.
+	attachments []attachment
+
+	// lang is the language every response should be written in, or empty.
+	lang string
+
+	// config is the configuration resolved at startup, read from
+	// configPath, with the source of each setting, for /config.
+	config        Config
+	configPath    string
+	configSources configSources
+
+	// recallSize is how many recent responses /recall reaches back.
+	recallSize int
+
+	// presets are the named option bundles from the config, and preset the
+	// one applied. configOptions and flagOptions are the options from the
+	// config file and from the command line, which /preset restores and
+	// keeps respectively.
+	presets       map[string]map[string]any
+	preset        string
+	configOptions map[string]any
+	flagOptions   map[string]any
+
+	// lintCodeBlocks checks the code in every response with formatters,
+	// the commands per language; lintShow prints the formatted code.
+	lintCodeBlocks bool
+	lintShow       bool
+	formatters     map[string]string
+
+	// preMinimize is the history as it was before /minimize replaced it.
+	preMinimize []turn
+
+	// standing holds the /always instructions added to every request, in
+	// the order they were given.
+	standing []string
+
+	// timeout bounds a whole response; firstChunkTimeout bounds the wait
+	// for its first chunk and idleTimeout the gap between two later ones,
+	// so a wedged server or generation is caught early.
+	timeout           time.Duration
+	firstChunkTimeout time.Duration
+	idleTimeout       time.Duration
+
+	// userPrefix and userSuffix wrap user turns in requestMessages only.
+	userPrefix string
+	userSuffix string
+
+	// options are the model options sent with every request.
+	options map[string]any
+
+	sessionsDir  string
+	inputHistory string
+	personasDir  string
+
+	// autoSummarize is the context size at which older turns are
+	// summarized, or nil when disabled.
+	autoSummarize *summarizeThreshold
+
+	// incognito marks new turns so they are left out of saved sessions.
+	incognito bool
+
+	// shareFields are the front matter fields of /export-share.
+	shareFields []string
+
+	// allowShell permits commands that run external programs.
+	allowShell bool
+
+	// thinking is the reasoning policy for requests, display and history.
+	thinking ThinkingConfig
+
+	// trimLeadingNewlines drops newlines the model emits before the first
+	// visible text of a response.
+	trimLeadingNewlines bool
+
+	// autoContinueParts is how many continuations are requested for a
+	// response cut off at the length limit, up to autoContinueMaxChars.
+	autoContinueParts    int
+	autoContinueMaxChars int
+
+	// budget is a soft cap on generation time: when it passes the stream is
+	// stopped and the partial response kept. Zero means no cap.
+	budget time.Duration
+
+	// formatSchema constrains responses to a JSON schema, which schema
+	// validates them against. With schemaRetry an invalid response is
+	// re-requested once with the errors attached.
+	formatSchema json.RawMessage
+	schema       *jsonSchema
+	schemaRetry  bool
+
+	// retryEmpty is how many times an empty response is re-requested.
+	retryEmpty int
+
+	debug bool
+}
+
+// turn is one message of the history together with what the session keeps
+// about it. It encodes as the flat api.Message fields plus those of turnInfo.
+type turn struct {
+	api.Message
+	turnInfo
+}
+
+// turnInfo is the session's own data about a turn.
+type turnInfo struct {
+	// Meta is the final response chunk of an assistant turn, holding the
+	// server-reported model, timing and token counts.
+	Meta *api.ChatResponse `json:"meta,omitempty"`
+
+	// Parts keeps content that arrived as a list of typed parts, exactly as
+	// received; Message.Content then holds its display text, and requests
+	// send its text parts instead.
+	Parts []contentPart `json:"parts,omitempty"`
+
+	// TimeLimited marks a response cut short by the /budget time cap.
+	TimeLimited bool `json:"time_limited,omitempty"`
+
+	// Tags are the /tag labels given to the turn, in the order added.
+	Tags []string `json:"tags,omitempty"`
+
+	// Incognito turns stay in the conversation but are never written to
+	// disk.
+	Incognito bool `json:"-"`
+
+	// BaseSystem marks the turn holding the session's system prompt, which
+	// requests replace with the effective prompt. Other system turns, such
+	// as context files and inserted turns, are sent as they are.
+	BaseSystem bool `json:"base_system,omitempty"`
+}
+
+// UnmarshalJSON decodes both halves of a turn; without it the promoted
+// api.Message.UnmarshalJSON would skip the turnInfo fields. A content given
+// as a list of parts is kept in Parts and flattened into Content, with inline
+// images moved to Images.
+func (t *turn) UnmarshalJSON(b []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	text, parts, err := decodeContent(fields["content"])
+	if err != nil {
+		return err
+	}
+	if parts != nil {
+		fields["content"], _ = json.Marshal(text)
+		if b, err = json.Marshal(fields); err != nil {
+			return err
+		}
+	}
+
+	if err := json.Unmarshal(b, &t.Message); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, &t.turnInfo); err != nil {
+		return err
+	}
+	if parts != nil {
+		t.Parts = parts
+		for _, p := range parts {
+			if img, ok := p.image(); ok {
+				t.Images = append(t.Images, img)
+			}
+		}
+	}
+	return nil
+}
+
+// modelContent is the content sent to the model for t: the text of its
+// parts if it arrived as parts, so display markers never reach the model.
+func (t turn) modelContent() string {
+	if t.Parts != nil {
+		return partsContent(t.Parts)
+	}
+	return t.Content
+}
+
+var (
+	errStreamStalled = errors.New("stream stalled")
+	errNoFirstChunk  = errors.New("no response started")
+	errTimedOut      = errors.New("response timed out")
+	errBudgetReached = errors.New("time budget reached")
+)
+
+// newSession starts a conversation with the given system message, or with no
+// system turn when it is empty.
+func newSession(client *api.Client, model, system string) *session {
+	s := &session{
+		client: client,
+		in:     bufio.NewReader(os.Stdin),
+		model:  model,
+		system: system,
+	}
+	if system != "" {
+		s.messages = []turn{{
+			Message:  api.Message{Role: "system", Content: system},
+			turnInfo: turnInfo{BaseSystem: true},
+		}}
+	}
+	return s
+}
+
+// applyConfig copies the resolved configuration onto the session.
+func (s *session) applyConfig(cfg Config) {
+	if !strings.EqualFold(cfg.Lang, "off") {
+		s.lang = cfg.Lang
+	}
+	s.timeout = time.Duration(cfg.Timeout)
+	s.firstChunkTimeout = time.Duration(cfg.FirstChunkTimeout)
+	s.idleTimeout = time.Duration(cfg.IdleTimeout)
+	s.userPrefix = cfg.UserPrefix
+	s.userSuffix = cfg.UserSuffix
+	s.variables = cfg.Variables
+	s.options = maps.Clone(cfg.Options)
+	s.sessionsDir = cfg.SessionsDir
+	s.inputHistory = cfg.InputHistory
+	s.personasDir = cfg.PersonasDir
+	s.incognito = cfg.Incognito
+	s.shareFields = cfg.ShareFields
+	s.allowShell = cfg.AllowShell
+	s.thinking = cfg.Thinking
+	s.trimLeadingNewlines = cfg.TrimLeadingNewlines
+	s.retryEmpty = cfg.RetryEmpty
+	s.lintCodeBlocks, s.lintShow = cfg.LintCode, cfg.LintShow
+	s.formatters = cfg.Formatters
+	s.presets = cfg.Presets
+	s.preset = cfg.Preset
+	s.recallSize = cfg.RecallSize
+	s.autoContinueParts = cfg.AutoContinue
+	s.autoContinueMaxChars = cfg.AutoContinueMaxChars
+	if cfg.AutoSummarizeAt != "" {
+		t, err := parseSummarizeThreshold(cfg.AutoSummarizeAt)
+		if err != nil {
+			fmt.Fprintf(ui, "%s⚠️  Auto-summarize disabled:%s %v\n", Yellow, Reset, err)
+		} else {
+			s.autoSummarize = &t
+		}
+	}
+	s.debug = cfg.Debug
+}
+
+// prompt returns the input prompt, marked with the session's modes.
+func (s *session) prompt() string {
+	p := Green + "📝 You: " + Reset
+	if n := len(s.standing); n > 0 {
+		p = fmt.Sprintf("%s📌%d%s %s", Cyan, n, Reset, p)
+	}
+	if s.incognito {
+		p = Purple + "🕶️  incognito " + Reset + p
+	}
+	return p
+}
+
+// persistedTurns returns the turns that may be written to disk.
+func (s *session) persistedTurns() []turn {
+	turns := make([]turn, 0, len(s.messages))
+	for _, t := range s.messages {
+		if !t.Incognito {
+			turns = append(turns, t)
+		}
+	}
+	return turns
+}
+
+// systemPrompt returns the base system message with the session's standing
+// instructions appended to it.
+func (s *session) systemPrompt() string {
+	var parts []string
+	if s.system != "" {
+		parts = append(parts, s.system)
+	}
+	if s.lang != "" {
+		parts = append(parts, langInstruction(s.lang))
+	}
+	if len(s.standing) > 0 {
+		parts = append(parts, standingInstructions(s.standing))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// requestMessages returns a copy of the history to send to the model, with
+// the base system turn replaced by the effective system prompt and every user turn
+// wrapped in the configured prefix and suffix.
+func (s *session) requestMessages() []api.Message {
+	return s.historyRequest(len(s.messages))
+}
+
+// historyRequest is requestMessages for the first n turns.
+func (s *session) historyRequest(n int) []api.Message {
+	return s.turnsRequest(s.messages[:n])
+}
+
+// turnsRequest builds the request messages for any list of turns, as
+// requestMessages does for the history. Without a base system turn, one is
+// put first only when there are instructions to put in it.
+func (s *session) turnsRequest(turns []turn) []api.Message {
+	msgs := make([]api.Message, 0, len(turns)+1)
+	base := slices.ContainsFunc(turns, func(t turn) bool { return t.BaseSystem })
+	if !base && s.systemPrompt() != "" {
+		msgs = append(msgs, api.Message{Role: "system", Content: s.systemPrompt()})
+	}
+	for _, t := range turns {
+		msg := t.Message
+		msg.Content = t.modelContent()
+		switch {
+		case t.BaseSystem:
+			msg.Content = s.systemPrompt()
+		case msg.Role == "user":
+			msg.Content = s.wrapUser(msg.Content)
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+func (s *session) wrapUser(content string) string {
+	parts := make([]string, 0, 3)
+	for _, p := range []string{s.userPrefix, content, s.userSuffix} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// hasCapability reports whether the model advertises c.
+func (s *session) hasCapability(c model.Capability) bool {
+	for _, have := range s.capabilities {
+		if have == c {
+			return true
+		}
+	}
+	return false
+}
+
+// lastAssistant returns the index of the most recent assistant turn, or -1.
+func (s *session) lastAssistant() int {
+	return s.lastRole("assistant")
+}
+
+// lastRole returns the index of the last turn with the given role, or -1.
+func (s *session) lastRole(role string) int {
+	for i := len(s.messages) - 1; i >= 0; i-- {
+		if s.messages[i].Role == role {
+			return i
+		}
+	}
+	return -1
+}
+
+// send adds text to the history as a user turn, streams the model's reply to
+// stdout and records it as an assistant turn.
+func (s *session) send(text string) {
+	msg := api.Message{Role: "user", Content: text}
+	s.applyAttachments(&msg)
+	s.messages = append(s.messages, turn{Message: msg, turnInfo: turnInfo{Incognito: s.incognito}})
+	s.warnContextBudget()
+
+	req := s.chatRequest(s.requestMessages())
+	req.Format = s.formatSchema
+	reply, final, err := s.chat(req)
+	for attempt := 1; err == nil && strings.TrimSpace(reply.Content) == "" && attempt <= s.retryEmpty; attempt++ {
+		fmt.Fprintf(ui, "%s🔁 Empty response, retrying with a new seed (%d/%d)...%s\n", Yellow, attempt, s.retryEmpty, Reset)
+		setRequestOption(req, "seed", rand.IntN(math.MaxInt32))
+		reply, final, err = s.chat(req)
+	}
+	if err == nil && s.autoContinueParts > 0 {
+		reply, final, err = s.autoContinue(req.Messages, reply, final)
+	}
+	if err == nil && s.schema != nil {
+		reply, final, err = s.enforceSchema(req, reply, final)
+	}
+	limited := errors.Is(err, errBudgetReached)
+	if limited {
+		err = nil
+		fmt.Fprintf(ui, "\n%s⏳ Time budget of %s reached; keeping the partial response.%s", Yellow, s.budget, Reset)
+	}
+
+	if !s.thinking.Store {
+		reply.Thinking = ""
+	}
+	reply.Meta, reply.TimeLimited, reply.Incognito = final, limited, s.incognito
+	s.messages = append(s.messages, reply)
+
+	if err != nil {
+		s.reportError(err)
+	} else if strings.TrimSpace(reply.Content) == "" {
+		fmt.Fprintf(ui, "%s⚠️  The model returned an empty response.%s\n", Yellow, Reset)
+	}
+
+	// Final newline after response
+	fmt.Fprintln(out)
+	if err == nil && s.lintCodeBlocks {
+		s.lintCode(reply.Content, s.lintShow)
+	}
+	if err == nil && truncated(final) {
+		fmt.Fprintln(ui, Dim+"✂️  The response was cut off at the length limit; /continue for the rest."+Reset)
+	} else if err == nil && reply.Content != "" {
+		fmt.Fprintln(ui, Dim+"💡 /shorter · /longer · /refine <instruction>"+Reset)
+	}
+	if err == nil {
+		s.maybeAutoSummarize()
+	}
+}
+
+// complete sends msgs without streaming or printing and returns the reply.
+// It is used for the session's own bookkeeping requests.
+func (s *session) complete(msgs []api.Message) (string, error) {
+	ctx, cancel := s.requestContext()
+	defer cancel()
+
+	req := s.chatRequest(msgs)
+	req.Stream = new(bool)
+	var reply string
+	err := s.client.Chat(ctx, req, func(resp api.ChatResponse) error {
+		reply += resp.Message.Content
+		return nil
+	})
+	return reply, err
+}
+
+// enforceSchema validates a finished response against the session's schema
+// and, with schemaRetry, asks once for a corrected response. The failed
+// attempt and the correction note are not kept in the history.
+func (s *session) enforceSchema(req *api.ChatRequest, reply turn, final *api.ChatResponse) (turn, *api.ChatResponse, error) {
+	fmt.Fprintln(out)
+	errs := validateJSON(reply.Content, s.schema)
+	if !reportSchemaErrors(errs) || !s.schemaRetry {
+		return reply, final, nil
+	}
+
+	fmt.Fprintf(ui, "%s🔁 Asking for a corrected response...%s\n", Yellow, Reset)
+	retry := *req
+	retry.Messages = append(slices.Clip(req.Messages),
+		api.Message{Role: "assistant", Content: reply.modelContent()},
+		api.Message{Role: "user", Content: "Your previous reply did not match the required JSON schema:\n- " +
+			strings.Join(errs, "\n- ") + "\nReply again with only JSON that matches the schema."},
+	)
+	reply, final, err := s.chat(&retry)
+	if err == nil {
+		fmt.Fprintln(out)
+		reportSchemaErrors(validateJSON(reply.Content, s.schema))
+	}
+	return reply, final, err
+}
+
+// chatRequest builds a request for the session's model.
+func (s *session) chatRequest(msgs []api.Message) *api.ChatRequest {
+	req := &api.ChatRequest{
+		Model:    s.model,
+		Messages: msgs,
+		Options:  maps.Clone(s.options),
+	}
+	req.Think = s.thinkFor()
+	return req
+}
+
+// requestContext bounds a request by the response timeout, where zero means
+// no limit.
+func (s *session) requestContext() (context.Context, context.CancelFunc) {
+	if s.timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeoutCause(context.Background(), s.timeout, errTimedOut)
+}
+
+// chat streams one response to stdout and returns the assistant turn, with
+// its content, thinking and any parts, and the final chunk. A stall or timeout is
+// returned as errStreamStalled, errNoFirstChunk or errTimedOut, and a stream stopped by the
+// time budget as errBudgetReached along with the partial content.
+func (s *session) chat(req *api.ChatRequest) (turn, *api.ChatResponse, error) {
+	return s.chatStream(req, "")
+}
+
+// chatStream is chat for a response that continues previous. The opening of
+// the stream is held back until any text it repeats from the end of previous
+// has been trimmed, so the parts join cleanly on screen and in the reply.
+func (s *session) chatStream(req *api.ChatRequest, previous string) (turn, *api.ChatResponse, error) {
+	ctx, cancel := s.requestContext()
+	defer cancel()
+	ctx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+	var parts partsCollector
+	ctx = withPartsCollector(ctx, &parts)
+
+	// The watchdog cancels the request when no chunk arrives in time. Until
+	// the first chunk the wider first-chunk window applies, which leaves room
+	// for loading the model and reading a long prompt; from then on the idle
+	// window does, and every chunk pushes it back.
+	var watchdog *time.Timer
+	if s.firstChunkTimeout > 0 {
+		watchdog = time.AfterFunc(s.firstChunkTimeout, func() { stop(errNoFirstChunk) })
+	}
+	started := false
+	defer func() {
+		if watchdog != nil {
+			watchdog.Stop()
+		}
+	}()
+	if s.budget > 0 {
+		budget := time.AfterFunc(s.budget, func() { stop(errBudgetReached) })
+		defer budget.Stop()
+	}
+
+	if s.debug {
+		last := req.Messages[len(req.Messages)-1]
+		fmt.Fprintf(ui, "%s🐞 Sending %d messages, last %s turn:\n%s%s\n", Dim, len(req.Messages), last.Role, last.Content, Reset)
+	}
+
+	var fullResponse, fullThinking strings.Builder
+	var images []api.ImageData
+	var final *api.ChatResponse
+	thinking := s.newThinkingView()
+	defer thinking.finish()
+
+	var pending strings.Builder
+	var links linkStream
+	resolved := previous == ""
+	show := func(text string) {
+		if text != "" {
+			thinking.finish()
+			links.write(text)
+			fullResponse.WriteString(text)
+		}
+	}
+
+	err := s.client.Chat(ctx, req, func(resp api.ChatResponse) error {
+		if !started {
+			started = true
+			if watchdog != nil {
+				watchdog.Stop()
+			}
+			watchdog = nil
+			if s.idleTimeout > 0 {
+				watchdog = time.AfterFunc(s.idleTimeout, func() { stop(errStreamStalled) })
+			}
+		} else if watchdog != nil {
+			watchdog.Reset(s.idleTimeout)
+		}
+
+		thinking.add(resp.Message.Thinking)
+		fullThinking.WriteString(resp.Message.Thinking)
+		images = append(images, resp.Message.Images...)
+
+		// --- Stream Response ---
+		if s.trimLeadingNewlines && previous == "" && fullResponse.Len() == 0 {
+			resp.Message.Content = strings.TrimLeft(resp.Message.Content, "\r\n")
+		}
+		if resolved {
+			show(resp.Message.Content)
+		} else if pending.WriteString(resp.Message.Content); pending.Len() >= overlapWindow || resp.Done {
+			resolved = true
+			show(trimOverlap(previous, pending.String()))
+		}
+		if resp.Done {
+			resp.Message = api.Message{}
+			final = &resp
+		}
+		return nil
+	})
+	if !resolved {
+		show(trimOverlap(previous, pending.String()))
+	}
+	links.flush()
+	// The client ends a stream cut off by cancellation without an error, so
+	// a response that never finished takes its error from the context.
+	if err != nil || final == nil {
+		if cause := context.Cause(ctx); errors.Is(cause, errStreamStalled) || errors.Is(cause, errNoFirstChunk) || errors.Is(cause, errTimedOut) || errors.Is(cause, errBudgetReached) {
+			err = cause
+		}
+	}
+	reply := turn{
+		Message:  api.Message{Role: "assistant", Content: fullResponse.String(), Thinking: fullThinking.String(), Images: images},
+		turnInfo: turnInfo{Parts: parts.result()},
+	}
+	return reply, final, err
+}
+
+// reportError prints a failed generation, with advice for the failures the
+// session itself detects.
+func (s *session) reportError(err error) {
+	switch {
+	case errors.Is(err, errStreamStalled):
+		fmt.Fprintf(ui, "\n%s⏸️  Stream stalled:%s no output for %s, request cancelled.\n", Red, Reset, s.idleTimeout)
+		fmt.Fprintf(ui, "%s💡 The server may be wedged; try sending the message again.%s\n", Yellow, Reset)
+	case errors.Is(err, errNoFirstChunk):
+		fmt.Fprintf(ui, "\n%s⏸️  No response:%s nothing arrived within %s, request cancelled.\n", Red, Reset, s.firstChunkTimeout)
+		fmt.Fprintf(ui, "%s💡 The server accepted the request but never started answering; check that it is healthy and try again.%s\n", Yellow, Reset)
+	case errors.Is(err, errTimedOut):
+		fmt.Fprintf(ui, "\n%s⏱️  Timed out:%s no complete response within %s.\n", Red, Reset, s.timeout)
+	case errors.Is(err, errBudgetReached):
+		fmt.Fprintf(ui, "\n%s⏳ Time budget of %s reached; the response was cut short.%s\n", Yellow, s.budget, Reset)
+	default:
+		fmt.Fprintf(ui, "\n%s❌ Generation failed:%s %v%s\n", Red, Reset, err, Reset)
+	}
+}
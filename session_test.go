@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ollama/ollama/api"
+)
+
+func TestChatWatchdog(t *testing.T) {
+	savedOut := out
+	defer func() { out = savedOut }()
+	out = io.Discard
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		if r.URL.Query().Get("first") == "" {
+			fmt.Fprintln(w, `{"model":"m","message":{"role":"assistant","content":"hi"},"done":false}`)
+			w.(http.Flusher).Flush()
+		}
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name  string
+		query string
+		want  error
+	}{
+		{"no first chunk", "first=none", errNoFirstChunk},
+		{"stalled after first chunk", "", errStreamStalled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, _ := url.Parse(srv.URL + "?" + tt.query)
+			s := newSession(api.NewClient(base, http.DefaultClient), "m", "")
+			s.firstChunkTimeout, s.idleTimeout = 100*time.Millisecond, 50*time.Millisecond
+			_, _, err := s.chat(s.chatRequest([]api.Message{{Role: "user", Content: "hi"}}))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("chat error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// savedSession is the on-disk format of a saved conversation.
+type savedSession struct {
+	Model    string    `json:"model"`
+	SavedAt  time.Time `json:"saved_at"`
+	Messages []turn    `json:"messages"`
+}
+
+// sessionNamePart is what each slash-separated part of a session name or
+// folder may contain.
+var sessionNamePart = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+func defaultSessionsDir() string {
+	return filepath.Join(filepath.Dir(defaultConfigPath()), "sessions")
+}
+
+// validateSessionName checks a session name or folder such as
+// "project/notes", rejecting anything that could escape the sessions
+// directory.
+func validateSessionName(name string) error {
+	if name == "" {
+		return errors.New("name is empty")
+	}
+	for _, part := range strings.Split(name, "/") {
+		if !sessionNamePart.MatchString(part) || part == ".." {
+			return fmt.Errorf("invalid name %q: use letters, digits, '.', '_' and '-', with '/' between folders", name)
+		}
+	}
+	return nil
+}
+
+func (s *session) sessionPath(name string) string {
+	return filepath.Join(s.sessionsDir, filepath.FromSlash(name)+".json")
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place, so a crash never leaves a half-written session.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// moveNoClobber renames from to to, failing if to already exists. Linking
+// first makes the existence check and the move a single step.
+func moveNoClobber(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		return err
+	}
+	if err := os.Link(from, to); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("%s already exists", filepath.Base(to))
+		}
+		return err
+	}
+	return os.Remove(from)
+}
+
+func (s *session) saveSession(name string) (string, error) {
+	if err := validateSessionName(name); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(savedSession{
+		Model:    s.model,
+		SavedAt:  time.Now(),
+		Messages: s.persistedTurns(),
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := s.sessionPath(name)
+	return path, writeFileAtomic(path, data)
+}
+
+func (s *session) loadSession(name string) error {
+	if err := validateSessionName(name); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(s.sessionPath(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("no saved session %q", name)
+	}
+	if err != nil {
+		return err
+	}
+	var saved savedSession
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	for _, t := range saved.Messages {
+		if t.BaseSystem {
+			s.system = t.Content
+		}
+	}
+	s.messages = saved.Messages
+	if saved.Model != "" && saved.Model != s.model {
+		fmt.Fprintf(ui, "%s⚠️  Saved with %s; continuing with %s.%s\n", Yellow, saved.Model, s.model, Reset)
+	}
+	return nil
+}
+
+func (s *session) cmdSave(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(ui, "💾 Usage: /save <name>  (use folder/name to save into a folder)")
+		return
+	}
+	path, err := s.saveSession(args[0])
+	if err != nil {
+		fmt.Fprintf(ui, "%s❌ Save failed:%s %v\n", Red, Reset, err)
+		return
+	}
+	fmt.Fprintf(ui, "%s💾 Saved to%s %s\n", Green, Reset, path)
+	if skipped := len(s.messages) - len(s.persistedTurns()); skipped > 0 {
+		fmt.Fprintf(ui, "%s🕶️  %d incognito messages were not saved.%s\n", Dim, skipped, Reset)
+	}
+}
+
+func (s *session) cmdIncognito(args []string) {
+	switch {
+	case len(args) == 0:
+	case args[0] == "on":
+		s.incognito = true
+	case args[0] == "off":
+		s.incognito = false
+	default:
+		fmt.Fprintln(ui, "🕶️  Usage: /incognito [on|off]")
+		return
+	}
+	if s.incognito {
+		fmt.Fprintln(ui, Purple+"🕶️  Incognito on: new turns stay in this session but are never saved."+Reset)
+	} else {
+		fmt.Fprintln(ui, Green+"🕶️  Incognito off: new turns will be saved."+Reset)
+	}
+}
+
+func (s *session) cmdLoad(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(ui, "📂 Usage: /load <name>")
+		return
+	}
+	if err := s.loadSession(args[0]); err != nil {
+		fmt.Fprintf(ui, "%s❌ Load failed:%s %v\n", Red, Reset, err)
+		return
+	}
+	fmt.Fprintf(ui, "%s📂 Loaded %s%s (%d messages)\n", Green, args[0], Reset, len(s.messages))
+}
+
+// cmdSessions prints the sessions directory as a tree of folders and saved
+// sessions.
+func (s *session) cmdSessions() {
+	fmt.Fprintf(ui, "%s🗂️  Saved Sessions%s (%s):\n", Yellow, Reset, s.sessionsDir)
+	count := 0
+	err := filepath.WalkDir(s.sessionsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(s.sessionsDir, path)
+		if rel == "." || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		indent := strings.Repeat("  ", strings.Count(filepath.ToSlash(rel), "/")+1)
+		if d.IsDir() {
+			fmt.Fprintf(ui, "%s📁 %s/\n", indent, d.Name())
+			return nil
+		}
+		name, ok := strings.CutSuffix(d.Name(), ".json")
+		if !ok {
+			return nil
+		}
+		count++
+		modified := ""
+		if info, err := d.Info(); err == nil {
+			modified = info.ModTime().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(ui, "%s%s%s%s  %s%s%s\n", indent, Cyan, name, Reset, Dim, modified, Reset)
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintf(ui, "%s❌ %v%s\n", Red, err, Reset)
+		return
+	}
+	if count == 0 {
+		fmt.Fprintln(ui, "  (none yet — use /save <name>)")
+	}
+}
+
+// cmdSession handles /session rename and /session move.
+func (s *session) cmdSession(args []string) {
+	if len(args) != 3 || (args[0] != "rename" && args[0] != "move") {
+		fmt.Fprintln(ui, "🗂️  Usage: /session rename <old> <new> | /session move <name> <folder>")
+		return
+	}
+	from, to := args[1], args[2]
+	if args[0] == "move" {
+		to = path.Join(to, path.Base(from))
+		if args[2] == "." || args[2] == "/" {
+			to = path.Base(from)
+		}
+	}
+	for _, name := range []string{from, to} {
+		if err := validateSessionName(name); err != nil {
+			fmt.Fprintf(ui, "%s❌ %v%s\n", Red, err, Reset)
+			return
+		}
+	}
+	if _, err := os.Stat(s.sessionPath(from)); err != nil {
+		fmt.Fprintf(ui, "%s❌ No saved session %q%s\n", Red, from, Reset)
+		return
+	}
+	if err := moveNoClobber(s.sessionPath(from), s.sessionPath(to)); err != nil {
+		fmt.Fprintf(ui, "%s❌ Cannot %s %s:%s %v\n", Red, args[0], from, Reset, err)
+		return
+	}
+	fmt.Fprintf(ui, "%s🗂️  %s → %s%s\n", Green, from, to, Reset)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// shellTimeout bounds how long an external command may run.
+	shellTimeout = 30 * time.Second
+
+	// shellWaitDelay is how long runShell waits for output pipes to close
+	// after killing a command, in case something it started still holds them.
+	shellWaitDelay = 2 * time.Second
+
+	// maxShellOutput is how much command output is shown before truncating.
+	maxShellOutput = 64 << 10
+)
+
+// runShell runs command with sh, feeding it stdin, and returns its combined
+// output and exit code.
+func runShell(command, stdin string) ([]byte, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), shellTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	killProcessGroup(cmd)
+	cmd.WaitDelay = shellWaitDelay
+	cmd.Stdin = strings.NewReader(stdin)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return output.Bytes(), -1, fmt.Errorf("timed out after %s", shellTimeout)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return output.Bytes(), exitErr.ExitCode(), nil
+	}
+	return output.Bytes(), 0, err
+}
+
+// printShellOutput shows command output, truncating large output and
+// summarising binary output instead of writing it to the terminal.
+func printShellOutput(output []byte) {
+	switch {
+	case len(output) == 0:
+		fmt.Fprintln(ui, Dim+"(no output)"+Reset)
+	case !utf8.Valid(output) || bytes.ContainsRune(output, 0):
+		fmt.Fprintf(ui, "%s(binary output, %s)%s\n", Dim, formatBytes(int64(len(output))), Reset)
+	case len(output) > maxShellOutput:
+		fmt.Fprintln(ui, strings.TrimRight(string(output[:maxShellOutput]), "\n"))
+		fmt.Fprintf(ui, "%s… truncated, %s total%s\n", Dim, formatBytes(int64(len(output))), Reset)
+	default:
+		fmt.Fprintln(ui, strings.TrimRight(string(output), "\n"))
+	}
+}
+
+// cmdPipe sends the last response, or with -c its last code block, to an
+// external command's stdin.
+func (s *session) cmdPipe(command string) {
+	if !s.allowShell {
+		fmt.Fprintln(ui, Yellow+"🔒 Running commands is disabled; start with --allow-shell to enable /pipe."+Reset)
+		return
+	}
+	rest, codeOnly := strings.CutPrefix(command, "-c ")
+	if codeOnly {
+		command = strings.TrimSpace(rest)
+	}
+	if command == "" || command == "-c" {
+		fmt.Fprintln(ui, "🔧 Usage: /pipe [-c] <command>  (-c sends the last code block)")
+		return
+	}
+	i := s.lastAssistant()
+	if i < 0 {
+		fmt.Fprintln(ui, Yellow+"⚠️  No response to pipe yet."+Reset)
+		return
+	}
+	input := s.messages[i].Content
+	if codeOnly {
+		blocks := extractCodeBlocks(input)
+		if len(blocks) == 0 {
+			fmt.Fprintln(ui, Yellow+"⚠️  The last response has no code block."+Reset)
+			return
+		}
+		input = blocks[len(blocks)-1].code
+	}
+
+	fmt.Fprintf(ui, "%s🔧 %s%s\n", Purple, command, Reset)
+	output, code, err := runShell(command, input)
+	printShellOutput(output)
+	switch {
+	case err != nil:
+		fmt.Fprintf(ui, "%s❌ %v%s\n", Red, err, Reset)
+	case code != 0:
+		fmt.Fprintf(ui, "%s↳ exit status %d%s\n", Red, code, Reset)
+	default:
+		fmt.Fprintf(ui, "%s↳ exit status 0%s\n", Green, Reset)
+	}
+}
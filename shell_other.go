@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "os/exec"
+
+// killProcessGroup is a no-op where process groups aren't available; the
+// command itself is still killed and WaitDelay bounds the wait for its
+// children.
+func killProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ui receives prompts, status lines and other decoration; out receives the
+// model's response content. Both are stdout, except when stdout is
+// redirected while stdin is still a terminal (ollama-terminal > log.txt):
+// then the interface moves to stderr so it stays visible, and only the
+// responses are written to the redirected output.
+var (
+	ui  io.Writer = os.Stdout
+	out io.Writer = os.Stdout
+
+	// outColor is whether out is a terminal and may receive color codes.
+	outColor = true
+
+	// uiTerminal is whether ui is a terminal that understands cursor
+	// movement.
+	uiTerminal = true
+
+	// outHyperlinks is whether out is a terminal known to support OSC 8
+	// hyperlinks.
+	outHyperlinks = false
+)
+
+// hyperlinkTerminal reports whether the terminal described by the
+// environment supports OSC 8 hyperlinks. FORCE_HYPERLINK=1 or =0 overrides
+// the detection.
+func hyperlinkTerminal(getenv func(string) string) bool {
+	if v := getenv("FORCE_HYPERLINK"); v != "" {
+		return v != "0"
+	}
+	if getenv("TERM") == "dumb" {
+		return false
+	}
+	switch getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper", "Tabby":
+		return true
+	}
+	if getenv("WT_SESSION") != "" || getenv("KITTY_WINDOW_ID") != "" || getenv("KONSOLE_VERSION") != "" {
+		return true
+	}
+	// GNOME Terminal and other VTE terminals support them from 0.50.
+	if v, err := strconv.Atoi(getenv("VTE_VERSION")); err == nil && v >= 5000 {
+		return true
+	}
+	term := getenv("TERM")
+	for _, name := range []string{"kitty", "foot", "alacritty", "wezterm"} {
+		if strings.Contains(term, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// splitOutput reports whether the interface should move to stderr for the
+// given stdin and stdout terminal states.
+func splitOutput(stdinTTY, stdoutTTY bool) bool {
+	return stdinTTY && !stdoutTTY
+}
+
+func setupOutput() {
+	stdinTTY, stdoutTTY := isTerminal(os.Stdin), isTerminal(os.Stdout)
+	outColor = stdoutTTY
+	outHyperlinks = stdoutTTY && hyperlinkTerminal(os.Getenv)
+	uiTerminal = stdoutTTY
+	if splitOutput(stdinTTY, stdoutTTY) {
+		ui = os.Stderr
+		uiTerminal = isTerminal(os.Stderr)
+		fmt.Fprintln(ui, Dim+"📄 Output is redirected: responses go to stdout, the interface to stderr."+Reset)
+	}
+}
+
+// writeResponse writes a piece of response content to out.
+func writeResponse(text string) {
+	if outColor {
+		fmt.Fprint(out, Blue+text+Reset)
+		return
+	}
+	fmt.Fprint(out, text)
+}
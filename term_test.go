@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestSplitOutput(t *testing.T) {
+	tests := []struct {
+		name             string
+		stdinTTY, outTTY bool
+		want             bool
+	}{
+		{"interactive", true, true, false},
+		{"stdout redirected", true, false, true},
+		{"stdin piped", false, true, false},
+		{"fully piped", false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitOutput(tt.stdinTTY, tt.outTTY); got != tt.want {
+				t.Errorf("splitOutput(%v, %v) = %v, want %v", tt.stdinTTY, tt.outTTY, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/ollama/ollama/api"
+	"github.com/ollama/ollama/types/model"
+)
+
+// maxThinkingDots is how many dots fit on the progress line before it
+// starts over.
+const maxThinkingDots = 40
+
+var (
+	thinkingLevels  = []string{"off", "on", "low", "medium", "high"}
+	thinkingRenders = []string{"full", "dots", "hidden"}
+)
+
+// ThinkingConfig is the single policy for reasoning models: how hard they
+// think, whether and how the reasoning is displayed while it streams, and
+// whether it is kept in the history.
+type ThinkingConfig struct {
+	// Level is sent as ChatRequest.Think: "off", "on", or an effort of
+	// "low", "medium" or "high".
+	Level string `json:"level,omitempty"`
+
+	// Show turns the reasoning display on; Render picks its form: the
+	// full text, one dot per DotTokens tokens, or nothing.
+	Show      bool   `json:"show"`
+	Render    string `json:"render,omitempty"`
+	DotTokens int    `json:"dot_tokens,omitempty"`
+
+	// Store keeps the reasoning with each assistant turn, so it is sent
+	// back to the model and written to saved sessions.
+	Store bool `json:"store"`
+}
+
+func defaultThinkingConfig() ThinkingConfig {
+	return ThinkingConfig{Level: "low", Render: "full", DotTokens: 10}
+}
+
+func (c ThinkingConfig) validate() error {
+	if !slices.Contains(thinkingLevels, c.Level) {
+		return fmt.Errorf("thinking level %q must be one of %v", c.Level, thinkingLevels)
+	}
+	if !slices.Contains(thinkingRenders, c.Render) {
+		return fmt.Errorf("thinking render %q must be one of %v", c.Render, thinkingRenders)
+	}
+	if c.DotTokens < 1 {
+		return fmt.Errorf("thinking dot_tokens must be at least 1")
+	}
+	return nil
+}
+
+// setQuiet applies --quiet-thinking: when value is true, reasoning is shown
+// as progress dots. False leaves the settings as they are.
+func (c *ThinkingConfig) setQuiet(value string) error {
+	quiet, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	if quiet {
+		c.Show, c.Render = true, "dots"
+	}
+	return nil
+}
+
+// think returns the request's Think value.
+func (c ThinkingConfig) think() *api.ThinkValue {
+	switch c.Level {
+	case "off":
+		return &api.ThinkValue{Value: false}
+	case "on":
+		return &api.ThinkValue{Value: true}
+	}
+	return &api.ThinkValue{Value: c.Level}
+}
+
+// render returns how reasoning is displayed, folding Show into Render.
+func (c ThinkingConfig) render() string {
+	if !c.Show {
+		return "hidden"
+	}
+	return c.Render
+}
+
+func (c ThinkingConfig) String() string {
+	s := c.Level + ", " + c.render()
+	if c.render() == "dots" {
+		s += fmt.Sprintf(" (1 per %d tokens)", c.DotTokens)
+	}
+	if c.Store {
+		s += ", stored"
+	}
+	return s
+}
+
+// thinkFor returns the Think value for a request to the session's model, or
+// nil when the model or server cannot take one.
+func (s *session) thinkFor() *api.ThinkValue {
+	if !s.supportsThink() || !s.hasCapability(model.CapabilityThinking) {
+		return nil
+	}
+	return s.thinking.think()
+}
+
+// thinkingView renders a model's reasoning while it streams, following the
+// session's ThinkingConfig.
+type thinkingView struct {
+	render        string
+	tokensPerDot  int
+	tokens, shown int
+	active        bool
+}
+
+func (s *session) newThinkingView() *thinkingView {
+	render := s.thinking.render()
+	if render == "dots" && !uiTerminal {
+		render = "hidden"
+	}
+	return &thinkingView{render: render, tokensPerDot: s.thinking.DotTokens}
+}
+
+// add displays one streamed piece of thinking, roughly one token.
+func (v *thinkingView) add(text string) {
+	if v.render == "hidden" || text == "" {
+		return
+	}
+	if !v.active {
+		v.active = true
+		fmt.Fprint(ui, Dim+"💭 "+Reset)
+	}
+	if v.render == "full" {
+		fmt.Fprint(ui, Dim+text+Reset)
+		return
+	}
+
+	v.tokens++
+	if v.tokens%v.tokensPerDot != 0 {
+		return
+	}
+	if v.shown == maxThinkingDots {
+		v.shown = 0
+		fmt.Fprint(ui, "\r\033[K"+Dim+"💭 "+Reset)
+	}
+	v.shown++
+	fmt.Fprint(ui, Dim+"."+Reset)
+}
+
+// finish ends the reasoning display so the answer can start: full text is
+// separated by a blank line, the dots line is cleared.
+func (v *thinkingView) finish() {
+	if !v.active {
+		return
+	}
+	v.active = false
+	if v.render == "full" {
+		fmt.Fprint(ui, "\n\n")
+		return
+	}
+	fmt.Fprint(ui, "\r\033[K")
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+)
+
+func TestThinkingConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(*ThinkingConfig)
+		wantErr bool
+	}{
+		{"default", func(*ThinkingConfig) {}, false},
+		{"level off", func(c *ThinkingConfig) { c.Level = "off" }, false},
+		{"level on", func(c *ThinkingConfig) { c.Level = "on" }, false},
+		{"unknown level", func(c *ThinkingConfig) { c.Level = "max" }, true},
+		{"empty level", func(c *ThinkingConfig) { c.Level = "" }, true},
+		{"render dots", func(c *ThinkingConfig) { c.Render = "dots" }, false},
+		{"unknown render", func(c *ThinkingConfig) { c.Render = "fancy" }, true},
+		{"zero dot tokens", func(c *ThinkingConfig) { c.DotTokens = 0 }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := defaultThinkingConfig()
+			tt.edit(&c)
+			if err := c.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestThinkingConfigThink(t *testing.T) {
+	tests := []struct {
+		level string
+		want  any
+	}{
+		{"off", false},
+		{"on", true},
+		{"low", "low"},
+		{"medium", "medium"},
+		{"high", "high"},
+	}
+	for _, tt := range tests {
+		c := ThinkingConfig{Level: tt.level}
+		if got := c.think(); got == nil || got.Value != tt.want {
+			t.Errorf("think() for %q = %v, want %v", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestThinkingConfigRender(t *testing.T) {
+	tests := []struct {
+		show   bool
+		render string
+		want   string
+	}{
+		{false, "full", "hidden"},
+		{false, "dots", "hidden"},
+		{true, "full", "full"},
+		{true, "dots", "dots"},
+		{true, "hidden", "hidden"},
+	}
+	for _, tt := range tests {
+		c := ThinkingConfig{Show: tt.show, Render: tt.render}
+		if got := c.render(); got != tt.want {
+			t.Errorf("render() with show=%v render=%q = %q, want %q", tt.show, tt.render, got, tt.want)
+		}
+	}
+}
+
+func TestQuietThinkingFlag(t *testing.T) {
+	tests := []struct {
+		args       []string
+		wantShow   bool
+		wantRender string
+		wantErr    bool
+	}{
+		{nil, false, "full", false},
+		{[]string{"--quiet-thinking"}, true, "dots", false},
+		{[]string{"--quiet-thinking=true"}, true, "dots", false},
+		{[]string{"--quiet-thinking=false"}, false, "full", false},
+		{[]string{"--quiet-thinking=maybe"}, false, "full", true},
+	}
+	for _, tt := range tests {
+		c := defaultThinkingConfig()
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.BoolFunc("quiet-thinking", "", c.setQuiet)
+		err := fs.Parse(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: Parse() = %v, wantErr %v", tt.args, err, tt.wantErr)
+		}
+		if c.Show != tt.wantShow || c.Render != tt.wantRender {
+			t.Errorf("%v: show=%v render=%q, want show=%v render=%q", tt.args, c.Show, c.Render, tt.wantShow, tt.wantRender)
+		}
+	}
+}
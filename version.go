@@ -0,0 +1,90 @@
+package main
+
+import (
+	"cmp"
+	"strconv"
+	"strings"
+)
+
+// thinkMinVersion is the first Ollama release that accepts ChatRequest.Think.
+var thinkMinVersion = serverVersion{Major: 0, Minor: 9, Patch: 0}
+
+// serverVersion is a parsed Ollama version such as "0.11.4" or
+// "0.12.0-rc1".
+type serverVersion struct {
+	Major, Minor, Patch int
+	Pre                 string
+
+	// Dev marks a development build, which reports 0.0.0 and is assumed to
+	// be newer than any release.
+	Dev bool
+}
+
+// parseVersion parses a version string, tolerating a leading "v", missing
+// minor or patch numbers and build metadata. ok is false when the string
+// does not start with a number.
+func parseVersion(s string) (v serverVersion, ok bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s, _, _ = strings.Cut(s, "+")
+	core, pre, _ := strings.Cut(s, "-")
+	parts := strings.SplitN(core, ".", 3)
+	nums := make([]int, 3)
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return serverVersion{}, false
+		}
+		nums[i] = n
+	}
+	v = serverVersion{Major: nums[0], Minor: nums[1], Patch: nums[2], Pre: pre}
+	v.Dev = v.Major == 0 && v.Minor == 0 && v.Patch == 0
+	return v, true
+}
+
+// versionAtLeast reports whether v is floor or newer. A pre-release sorts
+// before its release, and a development build is newer than everything.
+func versionAtLeast(v, floor serverVersion) bool {
+	if v.Dev {
+		return true
+	}
+	for _, d := range [][2]int{{v.Major, floor.Major}, {v.Minor, floor.Minor}, {v.Patch, floor.Patch}} {
+		if d[0] != d[1] {
+			return d[0] > d[1]
+		}
+	}
+	switch {
+	case v.Pre == floor.Pre:
+		return true
+	case v.Pre == "":
+		return true
+	case floor.Pre == "":
+		return false
+	}
+	return comparePre(v.Pre, floor.Pre) >= 0
+}
+
+// comparePre orders pre-release labels such as "rc9" and "rc10" by their
+// text and then by the number they end in.
+func comparePre(a, b string) int {
+	split := func(s string) (string, int, bool) {
+		i := len(s)
+		for i > 0 && s[i-1] >= '0' && s[i-1] <= '9' {
+			i--
+		}
+		n, err := strconv.Atoi(s[i:])
+		return s[:i], n, err == nil
+	}
+	pa, na, oka := split(a)
+	pb, nb, okb := split(b)
+	if pa != pb || !oka || !okb {
+		return strings.Compare(a, b)
+	}
+	return cmp.Compare(na, nb)
+}
+
+// supportsThink reports whether the server accepts the Think field. An
+// unknown version is assumed to be recent.
+func (s *session) supportsThink() bool {
+	v, ok := parseVersion(s.serverVersion)
+	return !ok || versionAtLeast(v, thinkMinVersion)
+}
@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want serverVersion
+		ok   bool
+	}{
+		{"0.11.4", serverVersion{Major: 0, Minor: 11, Patch: 4}, true},
+		{"v0.9.0", serverVersion{Major: 0, Minor: 9}, true},
+		{"0.12.0-rc1", serverVersion{Major: 0, Minor: 12, Pre: "rc1"}, true},
+		{"0.6", serverVersion{Major: 0, Minor: 6}, true},
+		{"1.2.3+abc", serverVersion{Major: 1, Minor: 2, Patch: 3}, true},
+		{"0.0.0", serverVersion{Dev: true}, true},
+		{" 0.10.1 ", serverVersion{Major: 0, Minor: 10, Patch: 1}, true},
+		{"unknown", serverVersion{}, false},
+		{"", serverVersion{}, false},
+		{"1.x.0", serverVersion{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseVersion(tt.in)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseVersion(%q) = %+v, %v; want %+v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestVersionAtLeast(t *testing.T) {
+	tests := []struct {
+		v, floor string
+		want     bool
+	}{
+		{"0.9.0", "0.9.0", true},
+		{"0.11.4", "0.9.0", true},
+		{"0.8.9", "0.9.0", false},
+		{"1.0.0", "0.9.9", true},
+		{"0.9.0-rc1", "0.9.0", false},
+		{"0.9.0", "0.9.0-rc1", true},
+		{"0.9.0-rc2", "0.9.0-rc1", true},
+		{"0.9.0-rc10", "0.9.0-rc9", true},
+		{"0.9.0-rc9", "0.9.0-rc10", false},
+		{"0.9.0-alpha", "0.9.0-beta", false},
+		{"0.0.0", "0.9.0", true},
+	}
+	for _, tt := range tests {
+		v, _ := parseVersion(tt.v)
+		floor, _ := parseVersion(tt.floor)
+		if got := versionAtLeast(v, floor); got != tt.want {
+			t.Errorf("versionAtLeast(%s, %s) = %v, want %v", tt.v, tt.floor, got, tt.want)
+		}
+	}
+}